@@ -0,0 +1,143 @@
+package hsdp
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	sdkschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/services/iam"
+)
+
+var (
+	_ provider.Provider                       = (*frameworkProvider)(nil)
+	_ provider.ProviderWithEphemeralResources = (*frameworkProvider)(nil)
+	_ provider.ProviderWithFunctions          = (*frameworkProvider)(nil)
+)
+
+// frameworkProvider is the terraform-plugin-framework half of the provider
+//
+// It is muxed with the SDKv2 provider and does not configure clients itself.
+// Instead, it shares the *config.Config produced by the SDKv2 provider so both
+// halves authenticate the same way and reuse the same client caches.
+type frameworkProvider struct {
+	build   string
+	primary *sdkschema.Provider
+}
+
+// NewFrameworkProvider returns the plugin-framework provider which is served
+// alongside primary, the SDKv2 provider
+func NewFrameworkProvider(build string, primary *sdkschema.Provider) provider.Provider {
+	return &frameworkProvider{
+		build:   build,
+		primary: primary,
+	}
+}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "hsdp"
+	resp.Version = p.build
+}
+
+// Schema mirrors the SDKv2 provider schema, muxed providers must declare
+// identical provider schemas
+func (p *frameworkProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"region":                schema.StringAttribute{Optional: true, Description: descriptions["region"]},
+			"environment":           schema.StringAttribute{Optional: true, Description: descriptions["environment"]},
+			"iam_url":               schema.StringAttribute{Optional: true, Description: descriptions["iam_url"]},
+			"idm_url":               schema.StringAttribute{Optional: true, Description: descriptions["idm_url"]},
+			"s3creds_url":           schema.StringAttribute{Optional: true, Description: descriptions["s3creds_url"]},
+			"notification_url":      schema.StringAttribute{Optional: true, Description: descriptions["notification_url"]},
+			"mdm_url":               schema.StringAttribute{Optional: true, Description: descriptions["mdm_url"]},
+			"service_id":            schema.StringAttribute{Optional: true, Description: descriptions["service_id"]},
+			"service_private_key":   schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["service_private_key"]},
+			"oauth2_client_id":      schema.StringAttribute{Optional: true, Description: descriptions["oauth2_client_id"]},
+			"oauth2_password":       schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["oauth2_password"]},
+			"org_admin_username":    schema.StringAttribute{Optional: true, Description: descriptions["org_admin_username"]},
+			"org_admin_password":    schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["org_admin_password"]},
+			"uaa_username":          schema.StringAttribute{Optional: true, Description: descriptions["uaa_username"]},
+			"uaa_password":          schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["uaa_password"]},
+			"uaa_url":               schema.StringAttribute{Optional: true, Description: descriptions["uaa_url"]},
+			"shared_key":            schema.StringAttribute{Optional: true, Description: descriptions["shared_key"]},
+			"secret_key":            schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["secret_key"]},
+			"cartel_host":           schema.StringAttribute{Optional: true, Description: descriptions["cartel_host"]},
+			"cartel_token":          schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["cartel_token"]},
+			"cartel_secret":         schema.StringAttribute{Optional: true, Sensitive: true, Description: descriptions["cartel_secret"]},
+			"cartel_no_tls":         schema.BoolAttribute{Optional: true, Description: descriptions["cartel_no_tls"]},
+			"cartel_skip_verify":    schema.BoolAttribute{Optional: true, Description: descriptions["cartel_skip_verify"]},
+			"retry_max":             schema.Int64Attribute{Optional: true, Description: descriptions["retry_max"]},
+			"debug_log":             schema.StringAttribute{Optional: true, Description: descriptions["debug_log"]},
+			"debug_stderr":          schema.BoolAttribute{Optional: true, Description: descriptions["debug_stderr"]},
+			"log_redact_fields":     schema.ListAttribute{Optional: true, ElementType: types.StringType, Description: descriptions["log_redact_fields"]},
+			"ai_inference_endpoint": schema.StringAttribute{Optional: true},
+			"credentials":           schema.StringAttribute{Optional: true},
+			"profile":               schema.StringAttribute{Optional: true, Description: descriptions["profile"]},
+			"credential_process":    schema.StringAttribute{Optional: true, Description: descriptions["credential_process"]},
+		},
+		Blocks: map[string]schema.Block{
+			"default_tags": schema.ListNestedBlock{
+				Description: descriptions["default_tags"],
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"tags": schema.MapAttribute{Optional: true, ElementType: types.StringType, Description: descriptions["default_tags.tags"]},
+					},
+				},
+			},
+			"retry_policy": schema.ListNestedBlock{
+				Description: descriptions["retry_policy"],
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"max_attempts":        schema.Int64Attribute{Optional: true, Description: descriptions["retry_policy.max_attempts"]},
+						"max_elapsed":         schema.StringAttribute{Optional: true, Description: descriptions["retry_policy.max_elapsed"]},
+						"retry_on":            schema.ListAttribute{Optional: true, ElementType: types.Int64Type, Description: descriptions["retry_policy.retry_on"]},
+						"respect_retry_after": schema.BoolAttribute{Optional: true, Description: descriptions["retry_policy.respect_retry_after"]},
+						"requests_per_second": schema.Float64Attribute{Optional: true, Description: descriptions["retry_policy.requests_per_second"]},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure hands the SDKv2 provider configuration to framework resources
+//
+// The mux server configures its servers in order, so the SDKv2 provider has
+// already been configured by the time this is called.
+func (p *frameworkProvider) Configure(_ context.Context, _ provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	c, ok := p.primary.Meta().(*config.Config)
+	if !ok || c == nil {
+		resp.Diagnostics.AddError("provider not configured",
+			"the SDKv2 provider must be configured before the framework provider")
+		return
+	}
+	resp.DataSourceData = c
+	resp.ResourceData = c
+	resp.EphemeralResourceData = c
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return []func() resource.Resource{}
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{}
+}
+
+func (p *frameworkProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		iam.NewIAMTokenEphemeralResource,
+		iam.NewIAMPrincipalTokenEphemeralResource,
+	}
+}
+
+func (p *frameworkProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{}
+}
@@ -0,0 +1,26 @@
+package hsdp
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+)
+
+// ProtoV5ProviderServerFactory returns a factory for the muxed provider server,
+// combining the SDKv2 provider with the plugin-framework provider
+func ProtoV5ProviderServerFactory(ctx context.Context, build string) (func() tfprotov5.ProviderServer, error) {
+	primary := Provider(build)
+
+	servers := []func() tfprotov5.ProviderServer{
+		primary.GRPCProvider, // Must come first, the framework provider uses its configuration
+		providerserver.NewProtocol5(NewFrameworkProvider(build, primary)),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, servers...)
+	if err != nil {
+		return nil, err
+	}
+	return muxServer.ProviderServer, nil
+}
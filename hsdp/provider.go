@@ -2,9 +2,10 @@ package hsdp
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"github.com/philips-software/terraform-provider-hsdp/internal/services/connect/dbs"
 	"os"
+	"time"
 
 	"github.com/philips-software/terraform-provider-hsdp/internal/services/blr"
 
@@ -12,8 +13,10 @@ import (
 
 	"github.com/google/fhir/go/fhirversion"
 	"github.com/google/fhir/go/jsonformat"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/philips-software/terraform-provider-hsdp/internal/config"
 	"github.com/philips-software/terraform-provider-hsdp/internal/services/ai/inference"
 	"github.com/philips-software/terraform-provider-hsdp/internal/services/ai/workspace"
@@ -71,6 +74,7 @@ const (
 	UAAPassword      = "HSDP_UAA_PASSWORD"
 	DebugLog         = "HSDP_DEBUG_LOG"
 	DebugStdErr      = "HSDP_DEBUG_STDERR"
+	Profile          = "HSDP_PROFILE"
 )
 
 // Provider returns an instance of the HSDP provider
@@ -80,14 +84,14 @@ func Provider(build string) *schema.Provider {
 			"region": {
 				Type:         schema.TypeString,
 				Optional:     true,
-				DefaultFunc:  schema.EnvDefaultFunc(Region, "us-east"),
+				DefaultFunc:  schema.EnvDefaultFunc(Region, nil),
 				Description:  descriptions["region"],
 				ValidateFunc: tools.ValidateRegion,
 			},
 			"environment": {
 				Type:         schema.TypeString,
 				Optional:     true,
-				DefaultFunc:  schema.EnvDefaultFunc(Environment, "client-test"),
+				DefaultFunc:  schema.EnvDefaultFunc(Environment, nil),
 				Description:  descriptions["environment"],
 				ValidateFunc: tools.ValidateEnvironment,
 			},
@@ -247,6 +251,71 @@ func Provider(build string) *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc(DebugStdErr, nil),
 				Description: descriptions["debug_stderr"],
 			},
+			"retry_policy": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: descriptions["retry_policy"],
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"max_attempts": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      config.DefaultRetryMaxAttempts,
+							ValidateFunc: validation.IntBetween(1, 20),
+							Description:  descriptions["retry_policy.max_attempts"],
+						},
+						"max_elapsed": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      config.DefaultRetryMaxElapsed.String(),
+							ValidateFunc: validateDuration,
+							Description:  descriptions["retry_policy.max_elapsed"],
+						},
+						"retry_on": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeInt},
+							Description: descriptions["retry_policy.retry_on"],
+						},
+						"respect_retry_after": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: descriptions["retry_policy.respect_retry_after"],
+						},
+						"requests_per_second": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.FloatAtLeast(0),
+							Description:  descriptions["retry_policy.requests_per_second"],
+						},
+					},
+				},
+			},
+			"default_tags": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: descriptions["default_tags"],
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tags": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: descriptions["default_tags.tags"],
+						},
+					},
+				},
+			},
+			"log_redact_fields": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: descriptions["log_redact_fields"],
+			},
 			"ai_inference_endpoint": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -255,14 +324,27 @@ func Provider(build string) *schema.Provider {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(Profile, nil),
+				Description: descriptions["profile"],
+			},
+			"credential_process": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: descriptions["credential_process"],
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"hsdp_iam_org":                                   organization.ResourceIAMOrg(),
 			"hsdp_iam_group":                                 group.ResourceIAMGroup(),
 			"hsdp_iam_role":                                  role.ResourceIAMRole(),
+			"hsdp_iam_role_permission":                       role.ResourceIAMRolePermission(),
 			"hsdp_iam_proposition":                           proposition.ResourceIAMProposition(),
 			"hsdp_iam_application":                           application.ResourceIAMApplication(),
 			"hsdp_iam_user":                                  user.ResourceIAMUser(),
+			"hsdp_iam_users_batch":                           user.ResourceIAMUsersBatch(),
 			"hsdp_iam_client":                                client.ResourceIAMClient(),
 			"hsdp_iam_service":                               service.ResourceIAMService(),
 			"hsdp_iam_mfa_policy":                            iam.ResourceIAMMFAPolicy(),
@@ -270,6 +352,8 @@ func Provider(build string) *schema.Provider {
 			"hsdp_iam_email_template":                        email_template.ResourceIAMEmailTemplate(),
 			"hsdp_s3creds_policy":                            s3creds.ResourceS3CredsPolicy(),
 			"hsdp_container_host":                            ch.ResourceContainerHost(),
+			"hsdp_container_host_power":                      ch.ResourceContainerHostPower(),
+			"hsdp_container_host_group":                      ch.ResourceContainerHostGroup(),
 			"hsdp_metrics_autoscaler":                        metrics.ResourceMetricsAutoscaler(),
 			"hsdp_cdr_org":                                   org.ResourceCDROrg(),
 			"hsdp_cdr_subscription":                          subscription.ResourceCDRSubscription(),
@@ -323,6 +407,9 @@ func Provider(build string) *schema.Provider {
 			"hsdp_connect_mdm_firmware_component_version":    mdm.ResourceConnectMDMFirmwareComponentVersion(),
 			"hsdp_connect_mdm_firmware_distribution_request": mdm.ResourceConnectMDMFirmwareDistributionRequest(),
 			"hsdp_iam_group_membership":                      group_membership.ResourceIAMGroupMembership(),
+			"hsdp_iam_group_user":                            group_membership.ResourceIAMGroupUser(),
+			"hsdp_iam_group_service":                         group_membership.ResourceIAMGroupService(),
+			"hsdp_iam_group_device":                          group_membership.ResourceIAMGroupDevice(),
 			"hsdp_dicom_notification":                        dicom.ResourceDICOMNotification(),
 			"hsdp_cdr_practitioner":                          practitioner.ResourceCDRPractitioner(),
 			"hsdp_iam_role_sharing_policy":                   role_sharing_policy.ResourceRoleSharingPolicy(),
@@ -337,7 +424,10 @@ func Provider(build string) *schema.Provider {
 			"hsdp_iam_user":                              user.DataSourceUser(),
 			"hsdp_iam_service":                           service.DataSourceService(),
 			"hsdp_iam_permissions":                       iam.DataSourceIAMPermissions(),
+			"hsdp_iam_effective_permissions":             iam.DataSourceIAMEffectivePermissions(),
 			"hsdp_iam_org":                               organization.DataSourceIAMOrg(),
+			"hsdp_iam_org_tree":                          organization.DataSourceIAMOrgTree(),
+			"hsdp_iam_catalog":                           organization.DataSourceIAMCatalog(),
 			"hsdp_iam_proposition":                       proposition.DataSourceIAMProposition(),
 			"hsdp_iam_application":                       application.DataSourceIAMApplication(),
 			"hsdp_s3creds_access":                        s3creds.DataSourceS3CredsAccess(),
@@ -399,6 +489,7 @@ func Provider(build string) *schema.Provider {
 			"hsdp_connect_mdm_service_agent":             mdm.DataSourceConnectMDMServiceAgent(),
 			"hsdp_connect_mdm_service_agents":            mdm.DataSourceConnectMDMServiceAgents(),
 			"hsdp_container_host":                        ch.DataSourceContainerHost(),
+			"hsdp_container_hosts":                       ch.DataSourceContainerHosts(),
 			"hsdp_iam_permission":                        iam.DataSourceIAMPermission(),
 			"hsdp_cdr_practitioner":                      practitioner.DataSourceCDRPractitioner(),
 			"hsdp_cdr_org":                               org.DataSourceCDROrg(),
@@ -416,33 +507,119 @@ var descriptions map[string]string
 
 func init() {
 	descriptions = map[string]string{
-		"region":              "The HSDP region to configure for",
-		"environment":         "The HSDP environment to configure for",
-		"iam_url":             "The HSDP IAM instance URL",
-		"idm_url":             "The HSDP IDM instance URL",
-		"s3creds_url":         "The HSDP S3 Credentials instance URL",
-		"notification_url":    "The HSDP Notification service base URL to use",
-		"mdm_url":             "The Connect MDM URL to use",
-		"oauth2_client_id":    "The OAuth2 client id",
-		"oauth2_password":     "The OAuth2 password",
-		"service_id":          "The service ID to use as Organization Admin",
-		"service_private_key": "The private key of the service ID",
-		"org_admin_username":  "The username of the Organization Admin",
-		"org_admin_password":  "The password of the Organization Admin",
-		"shared_key":          "The shared key",
-		"secret_key":          "The secret key",
-		"debug_log":           "The log file to write debugging output to",
-		"debug_stderr":        "Debug to stderr",
-		"cartel_host":         "The Cartel host",
-		"cartel_token":        "The Cartel token key",
-		"cartel_secret":       "The Cartel secret key",
-		"cartel_no_tls":       "Disable TLS for Cartel",
-		"cartel_skip_verify":  "Skip certificate verification",
-		"retry_max":           "Maximum number of retries for API requests",
-		"uaa_username":        "The username of the Cloudfoundry account to use",
-		"uaa_password":        "The password of the Cloudfoundry account to use",
-		"uaa_url":             "The URL of the UAA server",
+		"region":                           "The HSDP region to configure for",
+		"environment":                      "The HSDP environment to configure for",
+		"iam_url":                          "The HSDP IAM instance URL",
+		"idm_url":                          "The HSDP IDM instance URL",
+		"s3creds_url":                      "The HSDP S3 Credentials instance URL",
+		"notification_url":                 "The HSDP Notification service base URL to use",
+		"mdm_url":                          "The Connect MDM URL to use",
+		"oauth2_client_id":                 "The OAuth2 client id",
+		"oauth2_password":                  "The OAuth2 password",
+		"service_id":                       "The service ID to use as Organization Admin",
+		"service_private_key":              "The private key of the service ID",
+		"org_admin_username":               "The username of the Organization Admin",
+		"org_admin_password":               "The password of the Organization Admin",
+		"shared_key":                       "The shared key",
+		"secret_key":                       "The secret key",
+		"debug_log":                        "The log file to write redacted debugging output to",
+		"debug_stderr":                     "Write redacted debugging output to stderr",
+		"log_redact_fields":                "Additional JSON and form fields to redact from logged API requests and responses",
+		"cartel_host":                      "The Cartel host",
+		"cartel_token":                     "The Cartel token key",
+		"cartel_secret":                    "The Cartel secret key",
+		"cartel_no_tls":                    "Disable TLS for Cartel",
+		"cartel_skip_verify":               "Skip certificate verification",
+		"retry_max":                        "Maximum number of retries for API requests",
+		"retry_policy":                     "Retry and rate limit policy applied to every API client",
+		"default_tags":                     "Tags applied to every resource that supports tags or labels",
+		"default_tags.tags":                "Map of tags merged into the tags or labels of every supporting resource",
+		"retry_policy.max_attempts":        "Maximum number of attempts per request, including the first one",
+		"retry_policy.max_elapsed":         "Maximum time spent retrying a request",
+		"retry_policy.retry_on":            "HTTP status codes to retry. Defaults to 429, 500, 502, 503 and 504",
+		"retry_policy.respect_retry_after": "Wait as long as the Retry-After response header asks",
+		"retry_policy.requests_per_second": "Client side rate limit per service endpoint. 0 disables rate limiting",
+		"uaa_username":                     "The username of the Cloudfoundry account to use",
+		"uaa_password":                     "The password of the Cloudfoundry account to use",
+		"uaa_url":                          "The URL of the UAA server",
+		"profile":                          "The profile of the shared configuration file (~/.hsdp/config) to read settings from",
+		"credential_process":               "Command which prints the credentials to use as a JSON object",
+	}
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as 90s or 5m: %w", k, err))
+	}
+	return
+}
+
+// expandRetryPolicy reads the retry_policy block, falling back to the defaults
+func expandRetryPolicy(d *schema.ResourceData) config.RetryPolicy {
+	policy := config.DefaultRetryPolicy()
+	blocks := d.Get("retry_policy").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return policy
+	}
+	block := blocks[0].(map[string]interface{})
+	policy.MaxAttempts = block["max_attempts"].(int)
+	if elapsed, err := time.ParseDuration(block["max_elapsed"].(string)); err == nil {
+		policy.MaxElapsed = elapsed
+	}
+	if codes := block["retry_on"].([]interface{}); len(codes) > 0 {
+		policy.RetryOn = make([]int, 0, len(codes))
+		for _, code := range codes {
+			policy.RetryOn = append(policy.RetryOn, code.(int))
+		}
 	}
+	policy.RespectRetryAfter = block["respect_retry_after"].(bool)
+	policy.RequestsPerSecond = block["requests_per_second"].(float64)
+	return policy
+}
+
+// applyProfile fills in the settings the provider block and environment leave
+// empty, first from the credential_process output and then from the profile
+func applyProfile(ctx context.Context, d *schema.ResourceData, c *config.Config) error {
+	var profile config.Profile
+	if name := d.Get("profile").(string); name != "" {
+		path, err := config.DefaultConfigFile()
+		if err != nil {
+			return err
+		}
+		if profile, err = config.LoadProfile(path, name); err != nil {
+			return err
+		}
+		tflog.Debug(ctx, "using shared configuration profile", map[string]interface{}{"profile": name, "path": path})
+	}
+	credentialProcess := d.Get("credential_process").(string)
+	if credentialProcess == "" {
+		credentialProcess = profile.CredentialProcess()
+	}
+	if credentialProcess != "" {
+		credentials, err := config.RunCredentialProcess(ctx, credentialProcess)
+		if err != nil {
+			return err
+		}
+		applied := c.ApplyProfile(credentials)
+		tflog.Debug(ctx, "applied credential_process output", map[string]interface{}{"keys": applied})
+	}
+	applied := c.ApplyProfile(profile)
+	tflog.Debug(ctx, "applied profile settings", map[string]interface{}{"keys": applied})
+
+	if c.Region == "" {
+		c.Region = "us-east"
+	}
+	if c.Environment == "" {
+		c.Environment = "client-test"
+	}
+	// Values from a profile bypass the schema validation
+	if _, errs := tools.ValidateRegion(c.Region, "region"); len(errs) > 0 {
+		return errs[0]
+	}
+	if _, errs := tools.ValidateEnvironment(c.Environment, "environment"); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
 }
 
 func providerConfigure(build string) schema.ConfigureContextFunc {
@@ -481,10 +658,13 @@ func providerConfigure(build string) schema.ConfigureContextFunc {
 		c.AIInferenceEndpoint = d.Get("ai_inference_endpoint").(string)
 		c.MDMURL = d.Get("mdm_url").(string)
 
-		credentialsFile := d.Get("credentials").(string)
-		if credentialsFile != "" {
-			file, _ := os.ReadFile(credentialsFile)
-			_ = json.Unmarshal(file, &c)
+		if err := applyProfile(ctx, d, c); err != nil {
+			return nil, diag.FromErr(err)
+		}
+		if credentialsFile := d.Get("credentials").(string); credentialsFile != "" {
+			if err := c.LoadCredentialsFile(credentialsFile); err != nil {
+				return nil, diag.FromErr(err)
+			}
 		}
 		if c.DebugLog != "" {
 			debugFile, err := os.OpenFile(c.DebugLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
@@ -495,6 +675,19 @@ func providerConfigure(build string) schema.ConfigureContextFunc {
 		if c.DebugStdErr && c.DebugWriter == nil { // Crossplane
 			c.DebugWriter = os.Stderr
 		}
+		for _, field := range d.Get("log_redact_fields").([]interface{}) {
+			c.LogRedactFields = append(c.LogRedactFields, field.(string))
+		}
+		c.SetupLogging(ctx)
+		c.SetupRetryPolicy(expandRetryPolicy(d))
+		if blocks := d.Get("default_tags").([]interface{}); len(blocks) > 0 && blocks[0] != nil {
+			for k, v := range blocks[0].(map[string]interface{})["tags"].(map[string]interface{}) {
+				if c.DefaultTags == nil {
+					c.DefaultTags = make(map[string]string)
+				}
+				c.DefaultTags[k] = v.(string)
+			}
+		}
 		c.SetupIAMClient()
 		c.SetupS3CredsClient()
 		c.SetupCartelClient()
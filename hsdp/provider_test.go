@@ -1,9 +1,15 @@
 package hsdp_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/philips-software/terraform-provider-hsdp/hsdp"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProvider(t *testing.T) {
@@ -15,3 +21,47 @@ func TestProvider(t *testing.T) {
 func TestProvider_impl(t *testing.T) {
 	var _ = hsdp.Provider("v0.0.0")
 }
+
+func TestProtoV5ProviderServer(t *testing.T) {
+	ctx := context.Background()
+	factory, err := hsdp.ProtoV5ProviderServerFactory(ctx, "v0.0.0")
+	require.NoError(t, err)
+	server := factory()
+
+	// The mux server rejects differing provider schemas between SDKv2 and framework
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	require.NoError(t, err)
+	for _, d := range schemaResp.Diagnostics {
+		assert.NotEqual(t, tfprotov5.DiagnosticSeverityError, d.Severity, "%s: %s", d.Summary, d.Detail)
+	}
+	require.NotNil(t, schemaResp.Provider)
+
+	fake := fakehsdp.New(t)
+	configType := schemaResp.Provider.ValueType().(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(configType.AttributeTypes))
+	for name, attrType := range configType.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, value := range map[string]string{
+		"iam_url":            fake.URL,
+		"idm_url":            fake.URL,
+		"oauth2_client_id":   fakehsdp.ClientID,
+		"oauth2_password":    fakehsdp.ClientPassword,
+		"org_admin_username": fakehsdp.AdminUsername,
+		"org_admin_password": fakehsdp.AdminPassword,
+	} {
+		values[name] = tftypes.NewValue(tftypes.String, value)
+	}
+	config, err := tfprotov5.NewDynamicValue(configType, tftypes.NewValue(configType, values))
+	require.NoError(t, err)
+
+	// Both halves must be configured, the framework one from the SDKv2 configuration
+	configureResp, err := server.ConfigureProvider(ctx, &tfprotov5.ConfigureProviderRequest{
+		TerraformVersion: "1.11.0",
+		Config:           &config,
+	})
+	require.NoError(t, err)
+	for _, d := range configureResp.Diagnostics {
+		assert.NotEqual(t, tfprotov5.DiagnosticSeverityError, d.Severity, "%s: %s", d.Summary, d.Detail)
+	}
+}
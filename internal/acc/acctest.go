@@ -1,12 +1,15 @@
 package acc
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"sync"
 	"testing"
 
 	"github.com/philips-software/terraform-provider-hsdp/hsdp"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -32,6 +35,20 @@ func Skip(t *testing.T, message string) {
 // for tests requiring special provider configurations.
 var ProviderFactories map[string]func() (*schema.Provider, error)
 
+// ProtoV5ProviderFactories contains the muxed SDKv2 and plugin-framework provider
+//
+// Tests which exercise plugin-framework resources, data sources or ephemeral
+// resources must use these instead of ProviderFactories.
+var ProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	ProviderName: func() (tfprotov5.ProviderServer, error) {
+		factory, err := hsdp.ProtoV5ProviderServerFactory(context.Background(), "test")
+		if err != nil {
+			return nil, err
+		}
+		return factory(), nil
+	},
+}
+
 // Provider is the "main" provider instance
 //
 // This Provider can be used in testing code for API calls without requiring
@@ -80,6 +97,28 @@ func PreCheck(t *testing.T) {
 	})
 }
 
+// PreCheckFake verifies the prerequisites of unit tests which run the provider
+// against a fakehsdp server
+//
+// These tests need no HSDP credentials, but still require a Terraform CLI. CI
+// sets TF_ACC_TERRAFORM_PATH and the test fails when it points nowhere, or is
+// missing altogether. Elsewhere the test is skipped when terraform is not found
+// in PATH, so offline environments do not attempt a download.
+func PreCheckFake(t *testing.T) {
+	if path := os.Getenv("TF_ACC_TERRAFORM_PATH"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("TF_ACC_TERRAFORM_PATH: %v", err)
+		}
+		return
+	}
+	if os.Getenv("CI") != "" {
+		t.Fatal("TF_ACC_TERRAFORM_PATH must be set in CI to run fake HSDP tests")
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform CLI not found, set TF_ACC_TERRAFORM_PATH to run fake HSDP tests")
+	}
+}
+
 func AccUserGUID() string {
 	return os.Getenv("HSDP_IAM_ACC_USER_GUID")
 }
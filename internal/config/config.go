@@ -1,16 +1,15 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 
 	"github.com/philips-software/go-hsdp-api/connect/dbs"
 
 	"github.com/philips-software/go-hsdp-api/connect/blr"
 
 	"github.com/google/fhir/go/jsonformat"
-	"github.com/hashicorp/go-retryablehttp"
 	"github.com/philips-software/go-hsdp-api/ai"
 	"github.com/philips-software/go-hsdp-api/ai/inference"
 	"github.com/philips-software/go-hsdp-api/ai/workspace"
@@ -32,36 +31,38 @@ import (
 
 // Config contains configuration for the client
 type Config struct {
-	BuildVersion        string    `json:"-"`
-	ServiceID           string    `json:"service_id"`
-	ServicePrivateKey   string    `json:"service_private_key"`
-	S3CredsURL          string    `json:"s3_creds_url"`
-	NotificationURL     string    `json:"notification_url"`
-	IAMURL              string    `json:"iam_url"`
-	IDMURL              string    `json:"idm_url"`
-	SharedKey           string    `json:"shared_key"`
-	SecretKey           string    `json:"secret_key"`
-	MDMURL              string    `json:"mdm_url"`
-	Region              string    `json:"region"`
-	Environment         string    `json:"environment"`
-	OAuth2ClientID      string    `json:"oauth2_client_id"`
-	OAuth2ClientSecret  string    `json:"oauth2_client_secret"`
-	STLURL              string    `json:"stl_url"`
-	OrgAdminUsername    string    `json:"org_admin_username"`
-	OrgAdminPassword    string    `json:"org_admin_password"`
-	DebugLog            string    `json:"debug_log"`
-	DebugWriter         io.Writer `json:"-"`
-	CartelHost          string    `json:"cartel_host"`
-	CartelToken         string    `json:"cartel_token"`
-	CartelSecret        string    `json:"cartel_secret"`
-	CartelNoTLS         bool      `json:"cartel_no_tls"`
-	CartelSkipVerify    bool      `json:"cartel_skip_verify"`
-	RetryMax            int       `json:"retry_max"`
-	UAAUsername         string    `json:"uaa_username"`
-	UAAPassword         string    `json:"uaa_password"`
-	UAAURL              string    `json:"uaa_url"`
-	AIInferenceEndpoint string    `json:"ai_inference_endpoint"`
-	AIWorkspaceEndpoint string    `json:"ai_workspace_endpoint"`
+	BuildVersion        string            `json:"-"`
+	ServiceID           string            `json:"service_id"`
+	ServicePrivateKey   string            `json:"service_private_key"`
+	S3CredsURL          string            `json:"s3_creds_url"`
+	NotificationURL     string            `json:"notification_url"`
+	IAMURL              string            `json:"iam_url"`
+	IDMURL              string            `json:"idm_url"`
+	SharedKey           string            `json:"shared_key"`
+	SecretKey           string            `json:"secret_key"`
+	MDMURL              string            `json:"mdm_url"`
+	Region              string            `json:"region"`
+	Environment         string            `json:"environment"`
+	OAuth2ClientID      string            `json:"oauth2_client_id"`
+	OAuth2ClientSecret  string            `json:"oauth2_client_secret"`
+	STLURL              string            `json:"stl_url"`
+	OrgAdminUsername    string            `json:"org_admin_username"`
+	OrgAdminPassword    string            `json:"org_admin_password"`
+	DebugLog            string            `json:"debug_log"`
+	DebugWriter         io.Writer         `json:"-"`
+	CartelHost          string            `json:"cartel_host"`
+	CartelToken         string            `json:"cartel_token"`
+	CartelSecret        string            `json:"cartel_secret"`
+	CartelNoTLS         bool              `json:"cartel_no_tls"`
+	CartelSkipVerify    bool              `json:"cartel_skip_verify"`
+	RetryMax            int               `json:"retry_max"`
+	UAAUsername         string            `json:"uaa_username"`
+	UAAPassword         string            `json:"uaa_password"`
+	UAAURL              string            `json:"uaa_url"`
+	AIInferenceEndpoint string            `json:"ai_inference_endpoint"`
+	AIWorkspaceEndpoint string            `json:"ai_workspace_endpoint"`
+	LogRedactFields     []string          `json:"log_redact_fields"`
+	DefaultTags         map[string]string `json:"default_tags"`
 
 	iamClient             *iam.Client
 	cartelClient          *cartel.Client
@@ -74,6 +75,11 @@ type Config struct {
 	mdmClient             *mdm.Client
 	discoveryClient       *discovery.Client
 	dbsClient             *dbs.Client
+	principalClients      principalCache
+	logCtx                context.Context
+	redactor              *redactor
+	retryPolicy           *RetryPolicy
+	rateLimiter           *rateLimiter
 	DebugStdErr           bool `json:"debugging"`
 	credsClientErr        error
 	cartelClientErr       error
@@ -97,52 +103,59 @@ type Config struct {
 func (c *Config) IAMClient(principal ...*Principal) (*iam.Client, error) {
 	if len(principal) > 0 && principal[0] != nil && principal[0].HasAuth() {
 		p := principal[0]
-		cfg := iam.Config{
-			OAuth2ClientID: c.OAuth2ClientID,
-			OAuth2Secret:   c.OAuth2ClientSecret,
-			Region:         c.Region,
-			Environment:    c.Environment,
-			DebugLog:       c.DebugWriter,
-			SharedKey:      c.SharedKey,
-			SecretKey:      c.SecretKey,
-			IDMURL:         c.IDMURL,
-			IAMURL:         c.IAMURL,
-		}
-		if p.OAuth2ClientID != "" {
-			cfg.OAuth2ClientID = p.OAuth2ClientID
-		}
-		if p.OAuth2Password != "" {
-			cfg.OAuth2Secret = p.OAuth2Password
-		}
-		if p.Environment != "" {
-			cfg.Environment = p.Environment
-		}
-		if p.Region != "" {
-			cfg.Region = p.Region
-		}
-		iamClient, err := iam.NewClient(nil, &cfg)
+		return c.principalClients.get(principalKey(c, p), func() (*iam.Client, error) {
+			return c.principalIAMClient(p)
+		})
+	}
+	return c.iamClient, c.iamClientErr
+}
+
+// principalIAMClient returns a new IAM client logged in as principal
+func (c *Config) principalIAMClient(p *Principal) (*iam.Client, error) {
+	cfg := iam.Config{
+		OAuth2ClientID: c.OAuth2ClientID,
+		OAuth2Secret:   c.OAuth2ClientSecret,
+		Region:         c.Region,
+		Environment:    c.Environment,
+		DebugLog:       c.LogWriter(LogIAM),
+		SharedKey:      c.SharedKey,
+		SecretKey:      c.SecretKey,
+		IDMURL:         c.IDMURL,
+		IAMURL:         c.IAMURL,
+	}
+	if p.OAuth2ClientID != "" {
+		cfg.OAuth2ClientID = p.OAuth2ClientID
+	}
+	if p.OAuth2Password != "" {
+		cfg.OAuth2Secret = p.OAuth2Password
+	}
+	if p.Environment != "" {
+		cfg.Environment = p.Environment
+	}
+	if p.Region != "" {
+		cfg.Region = p.Region
+	}
+	iamClient, err := iam.NewClient(c.httpClient(LogIAM, nil), &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if p.Username != "" {
+		err := iamClient.Login(p.Username, p.Password)
 		if err != nil {
 			return nil, err
 		}
-		if p.Username != "" {
-			err := iamClient.Login(p.Username, p.Password)
-			if err != nil {
-				return nil, err
-			}
-			return iamClient, nil
-		}
-		if p.ServiceID != "" {
-			err := iamClient.ServiceLogin(iam.Service{
-				ServiceID:  p.ServiceID,
-				PrivateKey: p.ServicePrivateKey,
-			})
-			if err != nil {
-				return nil, err
-			}
-		}
 		return iamClient, nil
 	}
-	return c.iamClient, c.iamClientErr
+	if p.ServiceID != "" {
+		err := iamClient.ServiceLogin(iam.Service{
+			ServiceID:  p.ServiceID,
+			PrivateKey: p.ServicePrivateKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return iamClient, nil
 }
 
 func (c *Config) HasUAAuth() bool {
@@ -160,7 +173,7 @@ func (c *Config) DiscoveryClient(principal ...*Principal) (*discovery.Client, er
 		return discovery.NewClient(iamClient, &discovery.Config{
 			Region:      region,
 			Environment: environment,
-			DebugLog:    c.DebugWriter,
+			DebugLog:    c.LogWriter(LogDiscovery),
 		})
 	}
 	return c.discoveryClient, c.discoveryClientErr
@@ -177,7 +190,7 @@ func (c *Config) BLRClient(principal ...*Principal) (*blr.Client, error) {
 		return blr.NewClient(iamClient, &blr.Config{
 			Region:      region,
 			Environment: environment,
-			DebugLog:    c.DebugWriter,
+			DebugLog:    c.LogWriter(LogBLR),
 		})
 	}
 	return c.blrClient, c.blrClientErr
@@ -216,9 +229,9 @@ func (c *Config) ConsoleClient(principal ...*Principal) (*console.Client, error)
 			uaaPassword = p.UAAPassword
 		}
 	}
-	client, err := console.NewClient(nil, &console.Config{
+	client, err := console.NewClient(c.httpClient(LogConsole, nil), &console.Config{
 		Region:   region,
-		DebugLog: c.DebugWriter,
+		DebugLog: c.LogWriter(LogConsole),
 	})
 
 	if err != nil {
@@ -274,7 +287,7 @@ func (c *Config) STLClient(principal ...*Principal) (*stl.Client, error) {
 	client, err := stl.NewClient(consoleClient, &stl.Config{
 		Region:    region,
 		STLAPIURL: stlURL,
-		DebugLog:  c.DebugWriter,
+		DebugLog:  c.LogWriter(LogSTL),
 	})
 	if err != nil {
 		return nil, err
@@ -306,7 +319,7 @@ func (c *Config) PKIClient(principal ...*Principal) (*pki.Client, error) {
 		return pki.NewClient(c.consoleClient, iamClient, &pki.Config{
 			Region:      region,
 			Environment: environment,
-			DebugLog:    c.DebugWriter,
+			DebugLog:    c.LogWriter(LogPKI),
 		})
 	}
 	return c.pkiClient, c.pkiClientErr
@@ -322,7 +335,7 @@ func (c *Config) S3CredsClientWithLogin(username, password string) (*s3creds.Cli
 	}
 	return s3creds.NewClient(newIAMClient, &s3creds.Config{
 		BaseURL:  c.S3CredsURL,
-		DebugLog: c.DebugWriter,
+		DebugLog: c.LogWriter(LogS3Creds),
 	})
 }
 
@@ -347,7 +360,7 @@ func (c *Config) NotificationClient(principal ...*Principal) (*notification.Clie
 			Region:          region,
 			Environment:     environment,
 			NotificationURL: endpoint,
-			DebugLog:        c.DebugWriter,
+			DebugLog:        c.LogWriter(LogNotification),
 		})
 	}
 	return c.notificationClient, c.notificationClientErr
@@ -364,7 +377,7 @@ func (c *Config) DBSClient(principal ...*Principal) (*dbs.Client, error) {
 		return dbs.NewClient(iamClient, &dbs.Config{
 			Region:      region,
 			Environment: environment,
-			DebugLog:    c.DebugWriter,
+			DebugLog:    c.LogWriter(LogDBS),
 		})
 	}
 	return c.dbsClient, c.dbsClientErr
@@ -372,25 +385,19 @@ func (c *Config) DBSClient(principal ...*Principal) (*dbs.Client, error) {
 
 // SetupIAMClient sets up an HSDP IAM client
 func (c *Config) SetupIAMClient() {
-	var standardClient *http.Client
-	if c.RetryMax > 0 {
-		retryClient := retryablehttp.NewClient()
-		retryClient.RetryMax = c.RetryMax
-		standardClient = retryClient.StandardClient()
-	}
 	c.iamClient = nil
 	cfg := &iam.Config{
 		OAuth2ClientID: c.OAuth2ClientID,
 		OAuth2Secret:   c.OAuth2ClientSecret,
 		Region:         c.Region,
 		Environment:    c.Environment,
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogIAM),
 		SharedKey:      c.SharedKey,
 		SecretKey:      c.SecretKey,
 		IDMURL:         c.IDMURL,
 		IAMURL:         c.IAMURL,
 	}
-	client, err := iam.NewClient(standardClient, cfg)
+	client, err := iam.NewClient(c.httpClient(LogIAM, nil), cfg)
 	if err != nil {
 		c.iamClientErr = fmt.Errorf("possible invalid environment/region: %w", err)
 		return
@@ -445,7 +452,7 @@ func (c *Config) SetupSTLClient() {
 	}
 	client, err := stl.NewClient(c.consoleClient, &stl.Config{
 		STLAPIURL: c.STLURL,
-		DebugLog:  c.DebugWriter,
+		DebugLog:  c.LogWriter(LogSTL),
 	})
 	if err != nil {
 		c.stlClient = nil
@@ -475,7 +482,7 @@ func (c *Config) SetupS3CredsClient() {
 	}
 	client, err := s3creds.NewClient(c.iamClient, &s3creds.Config{
 		BaseURL:  c.S3CredsURL,
-		DebugLog: c.DebugWriter,
+		DebugLog: c.LogWriter(LogS3Creds),
 	})
 	if err != nil {
 		c.s3credsClient = nil
@@ -505,7 +512,7 @@ func (c *Config) SetupNotificationClient() {
 	}
 	client, err := notification.NewClient(c.iamClient, &notification.Config{
 		NotificationURL: c.NotificationURL,
-		DebugLog:        c.DebugWriter,
+		DebugLog:        c.LogWriter(LogNotification),
 	})
 	if err != nil {
 		c.notificationClient = nil
@@ -540,7 +547,7 @@ func (c *Config) SetupMDMClient() {
 	}
 	client, err := mdm.NewClient(c.iamClient, &mdm.Config{
 		BaseURL:  c.MDMURL,
-		DebugLog: c.DebugWriter,
+		DebugLog: c.LogWriter(LogMDM),
 	})
 	if err != nil {
 		c.mdmClient = nil
@@ -565,14 +572,14 @@ func (c *Config) SetupCartelClient() {
 		c.cartelClientErr = fmt.Errorf("missing Cartel token or secret, set 'cartel_token' and 'cartel_secret'")
 		return
 	}
-	client, err := cartel.NewClient(nil, &cartel.Config{
+	client, err := cartel.NewClient(c.httpClient(LogCartel, c.cartelTransport()), &cartel.Config{
 		Region:     c.Region,
 		Host:       c.CartelHost,
 		Token:      c.CartelToken,
 		Secret:     c.CartelSecret,
 		NoTLS:      c.CartelNoTLS,
 		SkipVerify: c.CartelSkipVerify,
-		DebugLog:   c.DebugWriter,
+		DebugLog:   c.LogWriter(LogCartel),
 	})
 	if err != nil {
 		c.cartelClient = nil
@@ -584,9 +591,9 @@ func (c *Config) SetupCartelClient() {
 
 // SetupConsoleClient sets up an Console client
 func (c *Config) SetupConsoleClient() {
-	client, err := console.NewClient(nil, &console.Config{
+	client, err := console.NewClient(c.httpClient(LogConsole, nil), &console.Config{
 		Region:   c.Region,
-		DebugLog: c.DebugWriter,
+		DebugLog: c.LogWriter(LogConsole),
 	})
 	if err != nil {
 		c.consoleClient = nil
@@ -615,7 +622,7 @@ func (c *Config) GetFHIRClientFromEndpoint(endpointURL string) (*cdr.Client, err
 		CDRURL:    "https://localhost.domain",
 		RootOrgID: "",
 		TimeZone:  c.TimeZone,
-		DebugLog:  c.DebugWriter,
+		DebugLog:  c.LogWriter(LogCDR),
 	})
 	if err != nil {
 		return nil, err
@@ -632,7 +639,7 @@ func (c *Config) GetCDLClientFromEndpoint(endpointURL string) (*cdl.Client, erro
 	}
 	client, err := cdl.NewClient(c.iamClient, &cdl.Config{
 		CDLURL:   "https://localhost.domain",
-		DebugLog: c.DebugWriter,
+		DebugLog: c.LogWriter(LogCDL),
 	})
 	if err != nil {
 		return nil, err
@@ -654,7 +661,7 @@ func (c *Config) GetCDLClient(baseURL, tenantID string) (*cdl.Client, error) {
 	client, err := cdl.NewClient(c.iamClient, &cdl.Config{
 		CDLURL:         baseURL,
 		OrganizationID: tenantID,
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogCDL),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetCDLClient: %w", err)
@@ -672,7 +679,7 @@ func (c *Config) GetAIInferenceClient(baseURL, tenantID string) (*inference.Clie
 	client, err := inference.NewClient(c.iamClient, &ai.Config{
 		BaseURL:        baseURL,
 		OrganizationID: tenantID,
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogAI),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("getAIInferenceClient: %w", err)
@@ -690,7 +697,7 @@ func (c *Config) GetAIInferenceClientFromEndpoint(endpointURL string) (*inferenc
 	client, err := inference.NewClient(c.iamClient, &ai.Config{
 		BaseURL:        "http://localhost",
 		OrganizationID: "not-set",
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogAI),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("getAIInferenceClientFromEndpoint: %w", err)
@@ -711,7 +718,7 @@ func (c *Config) GetAIWorkspaceClient(baseURL, tenantID string) (*workspace.Clie
 	client, err := workspace.NewClient(c.iamClient, &ai.Config{
 		BaseURL:        baseURL,
 		OrganizationID: tenantID,
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogAI),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("getAIWorkspaceClient: %w", err)
@@ -729,7 +736,7 @@ func (c *Config) GetAIWorkspaceClientFromEndpoint(endpointURL string) (*workspac
 	client, err := workspace.NewClient(c.iamClient, &ai.Config{
 		BaseURL:        "http://localhost",
 		OrganizationID: "not-set",
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogAI),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("getAIWorkspaceClientFromEndpoint: %w", err)
@@ -752,7 +759,7 @@ func (c *Config) GetFHIRClient(baseURL, rootOrgID string) (*cdr.Client, error) {
 		CDRURL:    baseURL,
 		RootOrgID: rootOrgID,
 		TimeZone:  c.TimeZone,
-		DebugLog:  c.DebugWriter,
+		DebugLog:  c.LogWriter(LogCDR),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetFHIRClient: %w", err)
@@ -761,9 +768,9 @@ func (c *Config) GetFHIRClient(baseURL, rootOrgID string) (*cdr.Client, error) {
 }
 
 func (c *Config) Debug(format string, a ...interface{}) (int, error) {
-	if c.DebugWriter != nil {
+	if w := c.LogWriter(""); w != nil {
 		output := fmt.Sprintf(format, a...)
-		return io.WriteString(c.DebugWriter, output)
+		return io.WriteString(w, output)
 	}
 	return 0, nil
 }
@@ -778,7 +785,7 @@ func (c *Config) GetDICOMConfigClient(url string) (*dicom.Client, error) {
 	client, err := dicom.NewClient(c.iamClient, &dicom.Config{
 		DICOMConfigURL: url,
 		TimeZone:       c.TimeZone,
-		DebugLog:       c.DebugWriter,
+		DebugLog:       c.LogWriter(LogDICOM),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("GetDICOMConfigClient: %w", err)
@@ -795,7 +802,7 @@ func (c *Config) SetupPKIClient() {
 	client, err := pki.NewClient(c.consoleClient, c.iamClient, &pki.Config{
 		Region:      c.Region,
 		Environment: c.Environment,
-		DebugLog:    c.DebugWriter,
+		DebugLog:    c.LogWriter(LogPKI),
 	})
 	if err != nil {
 		c.pkiClient = nil
@@ -814,7 +821,7 @@ func (c *Config) SetupDiscoveryClient() {
 	client, err := discovery.NewClient(c.iamClient, &discovery.Config{
 		Region:      c.Region,
 		Environment: c.Environment,
-		DebugLog:    c.DebugWriter,
+		DebugLog:    c.LogWriter(LogDiscovery),
 	})
 	if err != nil {
 		c.discoveryClient = nil
@@ -833,7 +840,7 @@ func (c *Config) SetupBLRClient() {
 	client, err := blr.NewClient(c.iamClient, &blr.Config{
 		Region:      c.Region,
 		Environment: c.Environment,
-		DebugLog:    c.DebugWriter,
+		DebugLog:    c.LogWriter(LogBLR),
 	})
 	if err != nil {
 		c.blrClient = nil
@@ -852,7 +859,7 @@ func (c *Config) SetupDBSClient() {
 	client, err := dbs.NewClient(c.iamClient, &dbs.Config{
 		Region:      c.Region,
 		Environment: c.Environment,
-		DebugLog:    c.DebugWriter,
+		DebugLog:    c.LogWriter(LogDBS),
 	})
 	if err != nil {
 		c.dbsClient = nil
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Logging subsystems of the clients created by Config. The level of each one
+// can be set through TF_LOG_PROVIDER_HSDP_<SUBSYSTEM>, e.g. TF_LOG_PROVIDER_HSDP_IAM
+const (
+	LogIAM          = "iam"
+	LogCartel       = "cartel"
+	LogS3Creds      = "s3creds"
+	LogConsole      = "console"
+	LogPKI          = "pki"
+	LogSTL          = "stl"
+	LogNotification = "notification"
+	LogMDM          = "mdm"
+	LogDiscovery    = "discovery"
+	LogBLR          = "blr"
+	LogDBS          = "dbs"
+	LogCDR          = "cdr"
+	LogCDL          = "cdl"
+	LogAI           = "ai"
+	LogDICOM        = "dicom"
+	LogFunction     = "function"
+)
+
+// logEnvPrefix is the tflog environment variable of this provider
+const logEnvPrefix = "TF_LOG_PROVIDER_HSDP"
+
+const redacted = "[redacted]"
+
+// defaultRedactFields are the JSON and form fields that are always redacted
+var defaultRedactFields = []string{
+	"password",
+	"privateKey",
+	"private_key",
+	"productKey",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"signed_token",
+	"token",
+	"id_token_hint",
+	"client_secret",
+	"assertion",
+	"secretKey",
+	"secret_key",
+	"sharedKey",
+	"shared_key",
+	"auth",
+	"passphrase",
+}
+
+var (
+	redactHeaders = regexp.MustCompile(`(?im)^((?:Authorization|Proxy-Authorization|X-User-Access-Token|Hsdp-Api-Signature|Api-Key|X-Api-Key|Cookie|Set-Cookie):)[^\r\n]*`)
+	redactBearer  = regexp.MustCompile(`(?i)\b(Bearer|Basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	redactPEM     = regexp.MustCompile(`(?s)-----BEGIN ([A-Z ]*)PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// redactor removes credentials from HTTP dumps and log messages
+type redactor struct {
+	json    *regexp.Regexp
+	form    *regexp.Regexp
+	secrets []string
+}
+
+// newRedactor returns a redactor for the default fields, the given extra
+// fields and the literal secrets
+func newRedactor(fields []string, secrets []string) *redactor {
+	names := make([]string, 0, len(defaultRedactFields)+len(fields))
+	for _, f := range append(append([]string{}, defaultRedactFields...), fields...) {
+		if f = strings.TrimSpace(f); f != "" {
+			names = append(names, regexp.QuoteMeta(f))
+		}
+	}
+	alternatives := strings.Join(names, "|")
+	r := &redactor{
+		json: regexp.MustCompile(`"(?i:(` + alternatives + `))"\s*:\s*"(?:[^"\\]|\\.)*"`),
+		form: regexp.MustCompile(`(?i)(^|[?&\s])(` + alternatives + `)=[^&\s"]*`),
+	}
+	for _, s := range secrets {
+		// Very short values would redact unrelated output
+		if len(s) >= 6 {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// Replace longer secrets first so a secret containing another is fully masked
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+func (r *redactor) redact(s string) string {
+	s = redactPEM.ReplaceAllString(s, "-----BEGIN ${1}PRIVATE KEY-----"+redacted+"-----END ${1}PRIVATE KEY-----")
+	s = redactHeaders.ReplaceAllString(s, "$1 "+redacted)
+	s = redactBearer.ReplaceAllString(s, "$1 "+redacted)
+	s = r.json.ReplaceAllString(s, `"$1": "`+redacted+`"`)
+	s = r.form.ReplaceAllString(s, "$1$2="+redacted)
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// logWriter forwards redacted client output to a tflog subsystem and,
+// when debug_log or debug_stderr is set, to the debug writer
+type logWriter struct {
+	ctx       context.Context
+	subsystem string
+	redactor  *redactor
+	sink      io.Writer
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	msg := w.redactor.redact(string(p))
+	if w.ctx != nil {
+		if w.subsystem == "" {
+			tflog.Debug(w.ctx, strings.TrimRight(msg, "\n"))
+		} else {
+			tflog.SubsystemDebug(w.ctx, w.subsystem, strings.TrimRight(msg, "\n"))
+		}
+	}
+	if w.sink != nil {
+		if _, err := io.WriteString(w.sink, msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// tflogEnabled reports whether Terraform logging is requested for subsystem
+func tflogEnabled(subsystem string) bool {
+	envs := []string{"TF_LOG", "TF_LOG_PROVIDER", logEnvPrefix}
+	if subsystem != "" {
+		envs = append(envs, logEnvPrefix+"_"+strings.ToUpper(subsystem))
+	}
+	for _, env := range envs {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupLogging prepares the redacting log writers of the clients. ctx must
+// carry the provider root logger, i.e. be the context passed to configure.
+func (c *Config) SetupLogging(ctx context.Context) {
+	c.logCtx = ctx
+	c.redactor = newRedactor(c.LogRedactFields, []string{
+		c.OAuth2ClientSecret,
+		c.ServicePrivateKey,
+		c.SharedKey,
+		c.SecretKey,
+		c.OrgAdminPassword,
+		c.CartelToken,
+		c.CartelSecret,
+		c.UAAPassword,
+	})
+}
+
+// LogWriter returns the writer to pass as DebugLog to a client of subsystem.
+// Output is redacted and sent to tflog and the debug_log / debug_stderr writer.
+// It returns nil when neither is enabled so clients skip dumping requests.
+func (c *Config) LogWriter(subsystem string) io.Writer {
+	if c.redactor == nil {
+		return nil
+	}
+	w := &logWriter{
+		subsystem: subsystem,
+		redactor:  c.redactor,
+		sink:      c.DebugWriter,
+	}
+	if c.logCtx != nil && tflogEnabled(subsystem) {
+		w.ctx = c.logCtx
+		if subsystem != "" {
+			w.ctx = tflog.NewSubsystem(c.logCtx, subsystem, tflog.WithLevelFromEnv(logEnvPrefix, subsystem))
+		}
+	}
+	if w.ctx == nil && w.sink == nil {
+		return nil
+	}
+	return w
+}
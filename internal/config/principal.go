@@ -1,6 +1,8 @@
 package config
 
 import (
+	ephemeralschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -80,6 +82,66 @@ func PrincipalSchema() *schema.Schema {
 	}
 }
 
+// PrincipalModel is the plugin-framework counterpart of the PrincipalSchema block
+type PrincipalModel struct {
+	Username          types.String `tfsdk:"username"`
+	Password          types.String `tfsdk:"password"`
+	OAuth2ClientID    types.String `tfsdk:"oauth2_client_id"`
+	OAuth2Password    types.String `tfsdk:"oauth2_password"`
+	Region            types.String `tfsdk:"region"`
+	Environment       types.String `tfsdk:"environment"`
+	ServiceID         types.String `tfsdk:"service_id"`
+	ServicePrivateKey types.String `tfsdk:"service_private_key"`
+	Endpoint          types.String `tfsdk:"endpoint"`
+	UAAUsername       types.String `tfsdk:"uaa_username"`
+	UAAPassword       types.String `tfsdk:"uaa_password"`
+}
+
+// PrincipalEphemeralBlock returns the PrincipalSchema block for ephemeral resources
+func PrincipalEphemeralBlock() ephemeralschema.SingleNestedBlock {
+	return ephemeralschema.SingleNestedBlock{
+		Attributes: map[string]ephemeralschema.Attribute{
+			"username":            ephemeralschema.StringAttribute{Optional: true},
+			"password":            ephemeralschema.StringAttribute{Optional: true, Sensitive: true},
+			"oauth2_client_id":    ephemeralschema.StringAttribute{Optional: true},
+			"oauth2_password":     ephemeralschema.StringAttribute{Optional: true, Sensitive: true},
+			"region":              ephemeralschema.StringAttribute{Optional: true},
+			"environment":         ephemeralschema.StringAttribute{Optional: true},
+			"service_id":          ephemeralschema.StringAttribute{Optional: true},
+			"service_private_key": ephemeralschema.StringAttribute{Optional: true, Sensitive: true},
+			"endpoint":            ephemeralschema.StringAttribute{Optional: true},
+			"uaa_username":        ephemeralschema.StringAttribute{Optional: true},
+			"uaa_password":        ephemeralschema.StringAttribute{Optional: true, Sensitive: true},
+		},
+	}
+}
+
+// ModelToPrincipal is the plugin-framework counterpart of SchemaToPrincipal
+func ModelToPrincipal(m *PrincipalModel, config *Config) *Principal {
+	principal := Principal{}
+	if m != nil {
+		principal.Endpoint = m.Endpoint.ValueString()
+		principal.Username = m.Username.ValueString()
+		principal.Password = m.Password.ValueString()
+		principal.ServiceID = m.ServiceID.ValueString()
+		principal.ServicePrivateKey = m.ServicePrivateKey.ValueString()
+		principal.Environment = m.Environment.ValueString()
+		principal.Region = m.Region.ValueString()
+		principal.OAuth2ClientID = m.OAuth2ClientID.ValueString()
+		principal.OAuth2Password = m.OAuth2Password.ValueString()
+		principal.UAAUsername = m.UAAUsername.ValueString()
+		principal.UAAPassword = m.UAAPassword.ValueString()
+	}
+	// Set defaults
+	if principal.Environment == "" {
+		principal.Environment = config.Environment
+	}
+	if principal.Region == "" {
+		principal.Region = config.Region
+	}
+	return &principal
+}
+
 func (p *Principal) HasAuth() bool {
 	// Service identity
 	if p.ServiceID != "" && p.ServicePrivateKey != "" {
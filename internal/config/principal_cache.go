@@ -0,0 +1,108 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+)
+
+// principalClientIdleTimeout is how long an unused principal client is kept
+const principalClientIdleTimeout = 30 * time.Minute
+
+// principalCache holds logged in IAM clients per principal so resources
+// sharing a principal block do not log in on every operation
+type principalCache struct {
+	mu      sync.Mutex
+	entries map[string]*principalEntry
+	now     func() time.Time
+}
+
+type principalEntry struct {
+	ready    chan struct{}
+	client   *iam.Client
+	err      error
+	lastUsed time.Time
+}
+
+// principalKey identifies a principal by its identity, credentials, region and
+// environment. Credentials are hashed so a changed secret results in a new login.
+func principalKey(c *Config, p *Principal) string {
+	h := sha256.New()
+	for _, field := range []string{
+		p.Username, p.Password,
+		p.ServiceID, p.ServicePrivateKey,
+		p.OAuth2ClientID, p.OAuth2Password,
+		p.Region, p.Environment,
+		c.IAMURL, c.IDMURL,
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (pc *principalCache) timeNow() time.Time {
+	if pc.now != nil {
+		return pc.now()
+	}
+	return time.Now()
+}
+
+// get returns the cached client for key, calling login once when there is none.
+// Concurrent callers for the same key wait for a single login. A client whose
+// token can no longer be refreshed is evicted and logged in again.
+func (pc *principalCache) get(key string, login func() (*iam.Client, error)) (*iam.Client, error) {
+	for attempt := 0; ; attempt++ {
+		entry, owner := pc.entry(key)
+		if owner {
+			entry.client, entry.err = login()
+			close(entry.ready)
+			if entry.err != nil {
+				pc.evict(key, entry)
+			}
+			return entry.client, entry.err
+		}
+		<-entry.ready
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		if _, err := entry.client.Token(); err != nil && attempt == 0 {
+			pc.evict(key, entry)
+			continue
+		}
+		return entry.client, nil
+	}
+}
+
+// entry returns the entry for key and whether the caller must populate it
+func (pc *principalCache) entry(key string) (*principalEntry, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	now := pc.timeNow()
+	if pc.entries == nil {
+		pc.entries = make(map[string]*principalEntry)
+	}
+	for k, e := range pc.entries {
+		if k != key && now.Sub(e.lastUsed) > principalClientIdleTimeout {
+			delete(pc.entries, k)
+		}
+	}
+	if e, ok := pc.entries[key]; ok && now.Sub(e.lastUsed) <= principalClientIdleTimeout {
+		e.lastUsed = now
+		return e, false
+	}
+	e := &principalEntry{ready: make(chan struct{}), lastUsed: now}
+	pc.entries[key] = e
+	return e, true
+}
+
+func (pc *principalCache) evict(key string, entry *principalEntry) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.entries[key] == entry {
+		delete(pc.entries, key)
+	}
+}
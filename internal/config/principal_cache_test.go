@@ -0,0 +1,102 @@
+package config
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tokenPath = "/authorize/oauth2/token"
+
+func fakeConfig(fake *fakehsdp.Server) *Config {
+	return &Config{
+		Region:             "us-east",
+		Environment:        "client-test",
+		IAMURL:             fake.URL,
+		IDMURL:             fake.URL,
+		OAuth2ClientID:     fakehsdp.ClientID,
+		OAuth2ClientSecret: fakehsdp.ClientPassword,
+	}
+}
+
+func TestIAMClientCachesPrincipals(t *testing.T) {
+	fake := fakehsdp.New(t)
+	c := fakeConfig(fake)
+	fake.AddUser("alice", "Passw0rd!alice", fake.RootOrgID)
+	alice := &Principal{Username: "alice", Password: "Passw0rd!alice"}
+
+	var wg sync.WaitGroup
+	clients := make([]*iam.Client, 50)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := c.IAMClient(alice)
+			assert.NoError(t, err)
+			clients[i] = client
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, fake.Requests("POST", tokenPath))
+	for _, client := range clients {
+		assert.Same(t, clients[0], client)
+	}
+
+	// Same identity, different secret or region results in a new login
+	_, err := c.IAMClient(&Principal{Username: "alice", Password: "wrong"})
+	assert.Error(t, err)
+	_, err = c.IAMClient(&Principal{Username: "alice", Password: "Passw0rd!alice", Region: "eu-west"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, fake.Requests("POST", tokenPath))
+
+	// The provider client is not affected
+	client, err := c.IAMClient()
+	assert.Nil(t, client)
+	assert.NoError(t, err)
+}
+
+func TestIAMClientDoesNotCacheFailures(t *testing.T) {
+	fake := fakehsdp.New(t)
+	c := fakeConfig(fake)
+	bob := &Principal{Username: "bob", Password: "Passw0rd!bob"}
+
+	_, err := c.IAMClient(bob)
+	assert.Error(t, err)
+
+	fake.AddUser("bob", "Passw0rd!bob", fake.RootOrgID)
+	client, err := c.IAMClient(bob)
+	require.NoError(t, err)
+	assert.NotNil(t, client)
+	assert.Equal(t, 2, fake.Requests("POST", tokenPath))
+}
+
+func TestIAMClientRefreshesAndEvicts(t *testing.T) {
+	fake := fakehsdp.New(t)
+	c := fakeConfig(fake)
+	now := time.Now()
+	c.principalClients.now = func() time.Time { return now }
+	fake.AddUser("carol", "Passw0rd!carol", fake.RootOrgID)
+	carol := &Principal{Username: "carol", Password: "Passw0rd!carol"}
+
+	first, err := c.IAMClient(carol)
+	require.NoError(t, err)
+
+	// An expired token is refreshed on the cached client
+	first.ExpireToken()
+	second, err := c.IAMClient(carol)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 2, fake.Requests("POST", tokenPath))
+
+	// Idle clients are evicted
+	now = now.Add(principalClientIdleTimeout + time.Minute)
+	third, err := c.IAMClient(carol)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, 3, fake.Requests("POST", tokenPath))
+}
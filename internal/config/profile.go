@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigFileEnv overrides the location of the shared configuration file
+const ConfigFileEnv = "HSDP_CONFIG_FILE"
+
+// CredentialProcessTimeout bounds how long a credential_process may run
+const CredentialProcessTimeout = time.Minute
+
+// profileFields maps the keys of a profile, and of credential_process output,
+// to the Config field they set
+var profileFields = map[string]func(c *Config) *string{
+	"region":              func(c *Config) *string { return &c.Region },
+	"environment":         func(c *Config) *string { return &c.Environment },
+	"iam_url":             func(c *Config) *string { return &c.IAMURL },
+	"idm_url":             func(c *Config) *string { return &c.IDMURL },
+	"service_id":          func(c *Config) *string { return &c.ServiceID },
+	"service_private_key": func(c *Config) *string { return &c.ServicePrivateKey },
+	"oauth2_client_id":    func(c *Config) *string { return &c.OAuth2ClientID },
+	"oauth2_password":     func(c *Config) *string { return &c.OAuth2ClientSecret },
+	"org_admin_username":  func(c *Config) *string { return &c.OrgAdminUsername },
+	"org_admin_password":  func(c *Config) *string { return &c.OrgAdminPassword },
+	"uaa_username":        func(c *Config) *string { return &c.UAAUsername },
+	"uaa_password":        func(c *Config) *string { return &c.UAAPassword },
+	"uaa_url":             func(c *Config) *string { return &c.UAAURL },
+	"shared_key":          func(c *Config) *string { return &c.SharedKey },
+	"secret_key":          func(c *Config) *string { return &c.SecretKey },
+	"cartel_host":         func(c *Config) *string { return &c.CartelHost },
+	"cartel_token":        func(c *Config) *string { return &c.CartelToken },
+	"cartel_secret":       func(c *Config) *string { return &c.CartelSecret },
+}
+
+// Profile keys which are not Config fields
+const (
+	profileCredentialProcess     = "credential_process"
+	profileServicePrivateKeyFile = "service_private_key_file"
+)
+
+// Profile holds the settings of a named profile of the shared configuration file
+type Profile map[string]string
+
+// DefaultConfigFile returns the location of the shared configuration file,
+// ~/.hsdp/config unless HSDP_CONFIG_FILE is set
+func DefaultConfigFile() (string, error) {
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating the shared configuration file: %w", err)
+	}
+	return filepath.Join(home, ".hsdp", "config"), nil
+}
+
+// LoadProfile reads the named profile from the shared configuration file at
+// path. Profiles are [profile name] sections, [default] is the default profile.
+func LoadProfile(path, name string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading shared configuration file: %w", err)
+	}
+	profiles, err := parseProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	if keyFile := profile[profileServicePrivateKeyFile]; keyFile != "" {
+		if strings.HasPrefix(keyFile, "~/") {
+			home, _ := os.UserHomeDir()
+			keyFile = filepath.Join(home, keyFile[2:])
+		}
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: reading service_private_key_file: %w", name, err)
+		}
+		profile["service_private_key"] = string(key)
+		delete(profile, profileServicePrivateKeyFile)
+	}
+	return profile, nil
+}
+
+func parseProfiles(data []byte) (map[string]Profile, error) {
+	profiles := make(map[string]Profile)
+	var current Profile
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section := strings.Join(strings.Fields(text[1:len(text)-1]), " ")
+			name := strings.TrimPrefix(section, "profile ")
+			if section != "default" && name == section {
+				return nil, fmt.Errorf("line %d: section [%s] should be [profile %s]", line, section, section)
+			}
+			if _, ok := profiles[name]; !ok {
+				profiles[name] = make(Profile)
+			}
+			current = profiles[name]
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: %q is outside a profile section", line, strings.TrimSpace(key))
+		}
+		key = strings.TrimSpace(key)
+		if err := validProfileKey(key, true); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		current[key] = strings.TrimSpace(value)
+	}
+	return profiles, scanner.Err()
+}
+
+func validProfileKey(key string, inFile bool) error {
+	if _, ok := profileFields[key]; ok {
+		return nil
+	}
+	if inFile && (key == profileCredentialProcess || key == profileServicePrivateKeyFile) {
+		return nil
+	}
+	return fmt.Errorf("unknown setting %q", key)
+}
+
+// CredentialProcess returns the credential_process command of the profile
+func (p Profile) CredentialProcess() string {
+	return p[profileCredentialProcess]
+}
+
+// RunCredentialProcess runs command through the shell and parses its output,
+// a JSON object with the same keys as a profile such as service_id and
+// service_private_key. Standard error is passed on to the provider logs.
+func RunCredentialProcess(ctx context.Context, command string) (Profile, error) {
+	ctx, cancel := context.WithTimeout(ctx, CredentialProcessTimeout)
+	defer cancel()
+
+	cmd := ShellCommand(ctx, command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential_process failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("credential_process failed: %w", err)
+	}
+	var output map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("credential_process output must be a JSON object of strings: %w", err)
+	}
+	for key := range output {
+		if err := validProfileKey(key, false); err != nil {
+			return nil, fmt.Errorf("credential_process output: %w", err)
+		}
+	}
+	return output, nil
+}
+
+// ShellCommand returns a command which runs command through the system shell
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// ApplyProfile sets the fields of c which are still empty from profile, so
+// provider arguments and environment variables take precedence. It returns
+// the keys that were applied.
+func (c *Config) ApplyProfile(profile Profile) []string {
+	var applied []string
+	for key, value := range profile {
+		field, ok := profileFields[key]
+		if !ok || value == "" {
+			continue
+		}
+		if target := field(c); *target == "" {
+			*target = value
+			applied = append(applied, key)
+		}
+	}
+	sort.Strings(applied)
+	return applied
+}
+
+// LoadCredentialsFile overlays c with the JSON credentials file at path
+func (c *Config) LoadCredentialsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading credentials file: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parsing credentials file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// RetryPolicy controls how every client created by Config retries failed
+// requests and how fast it may call a service
+type RetryPolicy struct {
+	MaxAttempts       int           `json:"max_attempts"`
+	MaxElapsed        time.Duration `json:"max_elapsed"`
+	RetryOn           []int         `json:"retry_on"`
+	RespectRetryAfter bool          `json:"respect_retry_after"`
+	RequestsPerSecond float64       `json:"requests_per_second"`
+}
+
+// Defaults of the provider retry_policy block
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryMaxElapsed  = 2 * time.Minute
+)
+
+// DefaultRetryOn are the status codes retried when retry_on is not set
+var DefaultRetryOn = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultRetryPolicy returns the policy used without a retry_policy block
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       DefaultRetryMaxAttempts,
+		MaxElapsed:        DefaultRetryMaxElapsed,
+		RetryOn:           DefaultRetryOn,
+		RespectRetryAfter: true,
+	}
+}
+
+// SetupRetryPolicy installs policy for the clients set up after it. The legacy
+// retry_max setting is honoured when it asks for more attempts.
+func (c *Config) SetupRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if c.RetryMax+1 > policy.MaxAttempts {
+		policy.MaxAttempts = c.RetryMax + 1
+	}
+	if len(policy.RetryOn) == 0 {
+		policy.RetryOn = DefaultRetryOn
+	}
+	c.retryPolicy = &policy
+	c.rateLimiter = &rateLimiter{
+		interval: rateInterval(policy.RequestsPerSecond),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// httpClient returns an HTTP client that applies the retry policy on top of
+// base, or nil when no policy is set up so clients use their own default
+func (c *Config) httpClient(subsystem string, base *http.Transport) *http.Client {
+	if c.retryPolicy == nil {
+		return nil
+	}
+	if base == nil {
+		base = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	return &http.Client{
+		Transport: &retryTransport{
+			next:    base,
+			policy:  *c.retryPolicy,
+			limiter: c.rateLimiter,
+			log:     c.LogWriter(subsystem),
+		},
+	}
+}
+
+// cartelTransport mirrors the transport the Cartel client creates by default
+func (c *Config) cartelTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: c.CartelSkipVerify},
+	}
+}
+
+func rateInterval(requestsPerSecond float64) time.Duration {
+	if requestsPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / requestsPerSecond)
+}
+
+// rateLimiter spaces requests to the same host at least interval apart
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func (l *rateLimiter) wait(ctx context.Context, host string) error {
+	if l == nil || l.interval == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	l.next[host] = start.Add(l.interval)
+	l.mu.Unlock()
+	return sleep(ctx, start.Sub(now))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryTransport retries requests according to a RetryPolicy
+type retryTransport struct {
+	next    http.RoundTripper
+	policy  RetryPolicy
+	limiter *rateLimiter
+	log     io.Writer
+}
+
+// idempotent reports whether a request can be repeated after it may have
+// been processed. Other requests are only retried when rejected with 429.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (t *retryTransport) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return idempotent(req)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return slices.Contains(t.policy.RetryOn, resp.StatusCode)
+	}
+	return idempotent(req) && slices.Contains(t.policy.RetryOn, resp.StatusCode)
+}
+
+// retryAfter parses the Retry-After header of resp
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = t.policy.MaxElapsed
+	b.Reset()
+
+	// Requests with a body that cannot be replayed are sent once
+	maxAttempts := t.policy.MaxAttempts
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		if err := t.limiter.wait(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+		r := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		resp, err := t.next.RoundTrip(r)
+		if !t.retryable(req, resp, err) {
+			return resp, err
+		}
+		wait := b.NextBackOff()
+		if after, ok := retryAfter(resp, time.Now()); ok && t.policy.RespectRetryAfter {
+			wait = after
+			if t.policy.MaxElapsed > 0 && b.GetElapsedTime()+after > t.policy.MaxElapsed {
+				wait = backoff.Stop
+			}
+		}
+		if attempt >= maxAttempts || wait == backoff.Stop {
+			if resp != nil {
+				resp.Header.Set(tools.RetriesExhaustedHeader, strconv.Itoa(attempt))
+			}
+			return resp, err
+		}
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		if t.log != nil {
+			_, _ = fmt.Fprintf(t.log, "retrying %s %s after %s: %s (attempt %d of %d)\n",
+				req.Method, req.URL.Redacted(), wait.Round(time.Millisecond), reason, attempt, maxAttempts)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
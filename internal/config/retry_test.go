@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusServer responds with the given status codes in turn, then 200
+func statusServer(t *testing.T, codes ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		body, _ := io.ReadAll(r.Body)
+		if n <= len(codes) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(codes[n-1])
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func retryConfig(policy RetryPolicy) *Config {
+	c := &Config{}
+	c.SetupRetryPolicy(policy)
+	return c
+}
+
+func TestRetryTransport(t *testing.T) {
+	c := retryConfig(DefaultRetryPolicy())
+	client := c.httpClient(LogIAM, nil)
+
+	// Idempotent requests are retried, bodies are replayed
+	server, calls := statusServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	req, _ := http.NewRequest(http.MethodPut, server.URL, bytes.NewBufferString("payload"))
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "payload", string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+
+	// Other requests are only retried when rate limited
+	server, calls = statusServer(t, http.StatusServiceUnavailable)
+	resp, err = client.Post(server.URL, "text/plain", bytes.NewBufferString("x"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(tools.RetriesExhaustedHeader))
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	server, calls = statusServer(t, http.StatusTooManyRequests)
+	resp, err = client.Post(server.URL, "text/plain", bytes.NewBufferString("x"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestRetryTransportExhausted(t *testing.T) {
+	c := retryConfig(RetryPolicy{MaxAttempts: 2, RespectRetryAfter: true})
+	client := c.httpClient(LogIAM, nil)
+	server, calls := statusServer(t, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests)
+
+	// Resource level retries stop once the policy is exhausted
+	var operations int
+	err := tools.TryHTTPCall(context.Background(), 10, func() (*http.Response, error) {
+		operations++
+		resp, err := client.Get(server.URL)
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = assert.AnError
+		}
+		return resp, err
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, operations)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestRetryTransportRetryAfterBeyondMaxElapsed(t *testing.T) {
+	c := retryConfig(RetryPolicy{MaxAttempts: 5, MaxElapsed: time.Second, RespectRetryAfter: true})
+	client := c.httpClient(LogIAM, nil)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(tools.RetriesExhaustedHeader))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRateLimiter(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.RequestsPerSecond = 20
+	c := retryConfig(policy)
+	client := c.httpClient(LogIAM, nil)
+	server, _ := statusServer(t)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestRetryMaxCompatibility(t *testing.T) {
+	c := &Config{RetryMax: 5}
+	c.SetupRetryPolicy(DefaultRetryPolicy())
+	assert.Equal(t, 6, c.retryPolicy.MaxAttempts)
+
+	assert.Nil(t, (&Config{}).httpClient(LogIAM, nil), "no policy set up")
+}
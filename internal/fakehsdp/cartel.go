@@ -0,0 +1,354 @@
+package fakehsdp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CartelToken is the Cartel token the fake accepts
+	CartelToken = "fake-cartel-token"
+	// CartelSecret is the Cartel secret the fake verifies request signatures with
+	CartelSecret = "fake-cartel-secret"
+
+	cartelPrefix = "/v3/api/"
+)
+
+// instance is a Cartel managed EC2 instance
+type instance struct {
+	InstanceID     string            `json:"instance_id"`
+	NameTag        string            `json:"name_tag"`
+	InstanceType   string            `json:"instance_type"`
+	LaunchTime     string            `json:"launch_time"`
+	LdapGroups     []string          `json:"ldap_groups"`
+	PrivateAddress string            `json:"private_address"`
+	Protection     bool              `json:"protection"`
+	Role           string            `json:"role"`
+	SecurityGroups []string          `json:"security_groups"`
+	BlockDevices   []string          `json:"block_devices"`
+	State          string            `json:"state"`
+	Subnet         string            `json:"subnet"`
+	Tags           map[string]string `json:"tags"`
+	Vpc            string            `json:"vpc"`
+	Zone           string            `json:"zone"`
+	Owner          string            `json:"owner"`
+}
+
+// cartelRequest is the union of the Cartel request bodies the fake understands
+type cartelRequest struct {
+	Token         string            `json:"token"`
+	NameTag       []string          `json:"name-tag"`
+	Role          string            `json:"role"`
+	SecurityGroup []string          `json:"security_group"`
+	LDAPGroups    []string          `json:"ldap_groups"`
+	InstanceType  string            `json:"instance_type"`
+	NumVolumes    int               `json:"num_vols"`
+	VolSize       int               `json:"vol_size"`
+	IOPs          int               `json:"iops"`
+	Subnet        string            `json:"subnet"`
+	SubnetType    string            `json:"subnet_type"`
+	Tags          map[string]string `json:"tags"`
+	Protect       bool              `json:"protect"`
+}
+
+func (s *Server) registerCartel(mux *http.ServeMux) {
+	for name, handler := range map[string]func(http.ResponseWriter, *cartelRequest){
+		"create":              s.createInstance,
+		"destroy":             s.destroyInstance,
+		"instance_details":    s.instanceDetails,
+		"deployment_status":   s.deploymentStatus,
+		"get_all_instances":   s.allInstances,
+		"suspend":             s.powerInstance(instanceStopped),
+		"start":               s.powerInstance(instanceRunning),
+		"add_tags":            s.tagInstance,
+		"protect":             s.protectInstance,
+		"get_security_groups": s.securityGroups,
+		"get_all_subnets":     s.subnets,
+	} {
+		mux.HandleFunc("POST "+cartelPrefix+name, s.cartelSigned(handler))
+	}
+}
+
+const (
+	instanceRunning = "running"
+	instanceStopped = "stopped"
+)
+
+// cartelSubnets are the subnets of the fake VPC, by name
+var cartelSubnets = map[string]map[string]string{
+	"private-1a": {"id": "subnet-private-1a", "network": "10.0.0.0/24"},
+	"public-1a":  {"id": "subnet-public-1a", "network": "10.0.100.0/24"},
+}
+
+// cartelSigned verifies the HMAC signature and token of a Cartel request
+func (s *Server) cartelSigned(next func(http.ResponseWriter, *cartelRequest)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeCartelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		hash := hmac.New(sha256.New, []byte(CartelSecret))
+		_, _ = hash.Write(body)
+		if r.Header.Get("Authorization") != base64.StdEncoding.EncodeToString(hash.Sum(nil)) {
+			writeCartelError(w, http.StatusForbidden, "invalid signature")
+			return
+		}
+		var req cartelRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeCartelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Token != CartelToken {
+			writeCartelError(w, http.StatusForbidden, "invalid token")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, &req)
+	}
+}
+
+func writeCartelError(w http.ResponseWriter, status int, description string) {
+	writeJSON(w, status, map[string]interface{}{
+		"code":        status,
+		"description": description,
+	})
+}
+
+// AddContainerHost seeds a running container host and returns its instance ID
+func (s *Server) AddContainerHost(name string, tags map[string]string) string {
+	return s.AddInstance(name, "container-host", "private", tags)
+}
+
+// AddInstance seeds a running instance with role in a subnet of subnetType
+// and returns its instance ID
+func (s *Server) AddInstance(name, role, subnetType string, tags map[string]string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addInstance(name, &cartelRequest{Role: role, SubnetType: subnetType, Tags: tags})
+}
+
+// ContainerHostState returns the run state of a container host, or "" when it does not exist
+func (s *Server) ContainerHostState(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.instances[name]; ok {
+		return i.State
+	}
+	return ""
+}
+
+// SetContainerHostState changes the run state of a container host out of band
+func (s *Server) SetContainerHostState(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.instances[name]; ok {
+		i.State = state
+	}
+}
+
+// RemoveContainerHost deletes a container host out of band
+func (s *Server) RemoveContainerHost(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.instances, name)
+}
+
+func (s *Server) addInstance(name string, req *cartelRequest) string {
+	instanceType := req.InstanceType
+	if instanceType == "" {
+		instanceType = "m5.large"
+	}
+	blockDevices := []string{"/dev/xvda"}
+	for n := 0; n < req.NumVolumes; n++ {
+		blockDevices = append(blockDevices, fmt.Sprintf("/dev/xvd%c", 'b'+n))
+	}
+	subnet := req.Subnet
+	if subnet == "" {
+		subnetType := req.SubnetType
+		if subnetType == "" {
+			subnetType = "private"
+		}
+		subnet = cartelSubnets[subnetType+"-1a"]["id"]
+	}
+	tags := map[string]string{"billing": ""}
+	for k, v := range req.Tags {
+		tags[k] = v
+	}
+	i := &instance{
+		InstanceID:     "i-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:17],
+		NameTag:        name,
+		InstanceType:   instanceType,
+		LaunchTime:     time.Now().UTC().Format(time.RFC3339),
+		LdapGroups:     req.LDAPGroups,
+		PrivateAddress: fmt.Sprintf("10.0.%d.%d", len(s.instances)/250, len(s.instances)%250+4),
+		Protection:     req.Protect,
+		Role:           req.Role,
+		SecurityGroups: append([]string{"base"}, req.SecurityGroup...),
+		BlockDevices:   blockDevices,
+		State:          instanceRunning,
+		Subnet:         subnet,
+		Tags:           tags,
+		Vpc:            "vpc-fake",
+		Zone:           "us-east-1a",
+		Owner:          "fake",
+	}
+	s.instances[name] = i
+	return i.InstanceID
+}
+
+// instancesOf returns the instances addressed by a request, failing on unknown names
+func (s *Server) instancesOf(w http.ResponseWriter, req *cartelRequest) ([]*instance, bool) {
+	var found []*instance
+	for _, name := range req.NameTag {
+		i, ok := s.instances[name]
+		if !ok {
+			writeCartelError(w, http.StatusBadRequest, fmt.Sprintf("Host named %s not found", name))
+			return nil, false
+		}
+		found = append(found, i)
+	}
+	return found, true
+}
+
+func (s *Server) createInstance(w http.ResponseWriter, req *cartelRequest) {
+	if len(req.NameTag) == 0 {
+		writeCartelError(w, http.StatusBadRequest, "name-tag is required")
+		return
+	}
+	for _, name := range req.NameTag {
+		if _, ok := s.instances[name]; ok {
+			writeCartelError(w, http.StatusBadRequest, fmt.Sprintf("Host named %s already exists!", name))
+			return
+		}
+	}
+	var created []map[string]interface{}
+	for _, name := range req.NameTag {
+		id := s.addInstance(name, req)
+		created = append(created, map[string]interface{}{
+			"instance_id": id,
+			"ip_address":  s.instances[name].PrivateAddress,
+			"name":        name,
+			"role":        req.Role,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"result":  "Success",
+		"message": created,
+	})
+}
+
+func (s *Server) destroyInstance(w http.ResponseWriter, req *cartelRequest) {
+	found, ok := s.instancesOf(w, req)
+	if !ok {
+		return
+	}
+	result := map[string]string{}
+	for _, i := range found {
+		if i.Protection {
+			writeCartelError(w, http.StatusBadRequest, fmt.Sprintf("Host named %s is protected", i.NameTag))
+			return
+		}
+		delete(s.instances, i.NameTag)
+		result[i.NameTag] = "Instance removed."
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"AWS": "ok", "Cartel": result})
+}
+
+// instanceDetails rejects the whole request when one of the names is unknown, like Cartel
+func (s *Server) instanceDetails(w http.ResponseWriter, req *cartelRequest) {
+	found, ok := s.instancesOf(w, req)
+	if !ok {
+		return
+	}
+	details := []map[string]*instance{}
+	for _, i := range found {
+		details = append(details, map[string]*instance{i.NameTag: i})
+	}
+	writeJSON(w, http.StatusOK, details)
+}
+
+func (s *Server) deploymentStatus(w http.ResponseWriter, req *cartelRequest) {
+	found, ok := s.instancesOf(w, req)
+	if !ok {
+		return
+	}
+	status := map[string]interface{}{}
+	for _, i := range found {
+		status[i.NameTag] = map[string]string{"deploy_state": "succeeded"}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) allInstances(w http.ResponseWriter, _ *cartelRequest) {
+	names := make([]string, 0, len(s.instances))
+	for name := range s.instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	all := make([]*instance, 0, len(names))
+	for _, name := range names {
+		all = append(all, s.instances[name])
+	}
+	writeJSON(w, http.StatusOK, all)
+}
+
+func (s *Server) powerInstance(state string) func(http.ResponseWriter, *cartelRequest) {
+	return func(w http.ResponseWriter, req *cartelRequest) {
+		found, ok := s.instancesOf(w, req)
+		if !ok {
+			return
+		}
+		for _, i := range found {
+			i.State = state
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"message": "ok"})
+	}
+}
+
+func (s *Server) tagInstance(w http.ResponseWriter, req *cartelRequest) {
+	found, ok := s.instancesOf(w, req)
+	if !ok {
+		return
+	}
+	for _, i := range found {
+		for k, v := range req.Tags {
+			if v == "" && k != "billing" {
+				delete(i.Tags, k)
+				continue
+			}
+			i.Tags[k] = v
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"message": "ok"})
+}
+
+func (s *Server) protectInstance(w http.ResponseWriter, req *cartelRequest) {
+	found, ok := s.instancesOf(w, req)
+	if !ok {
+		return
+	}
+	for _, i := range found {
+		i.Protection = req.Protect
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"message": "ok"})
+}
+
+func (s *Server) subnets(w http.ResponseWriter, _ *cartelRequest) {
+	writeJSON(w, http.StatusOK, cartelSubnets)
+}
+
+func (s *Server) securityGroups(w http.ResponseWriter, _ *cartelRequest) {
+	writeJSON(w, http.StatusOK, []string{"base", "http-access", "https-access"})
+}
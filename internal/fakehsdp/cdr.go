@@ -0,0 +1,197 @@
+package fakehsdp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Server) registerCDR(mux *http.ServeMux) {
+	base := "/store/fhir/{org}/{type}"
+	mux.HandleFunc("POST "+base, s.authorized(s.createFHIR))
+	mux.HandleFunc("GET "+base+"/{id}", s.authorized(s.getFHIR))
+	mux.HandleFunc("PUT "+base+"/{id}", s.authorized(s.putFHIR))
+	mux.HandleFunc("PATCH "+base+"/{id}", s.authorized(s.patchFHIR))
+	mux.HandleFunc("DELETE "+base+"/{id}", s.authorized(s.deleteFHIR))
+}
+
+func fhirKey(r *http.Request, id string) string {
+	return r.PathValue("org") + "/" + r.PathValue("type") + "/" + id
+}
+
+// storeFHIR stamps id and meta onto a resource and stores it
+func (s *Server) storeFHIR(w http.ResponseWriter, r *http.Request, id string, status int) {
+	resource := map[string]interface{}{}
+	if err := readJSON(r, &resource); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rt, _ := resource["resourceType"].(string); rt != r.PathValue("type") {
+		writeError(w, http.StatusBadRequest, "resourceType does not match the request path")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := fhirKey(r, id)
+	version := 1
+	if existing, ok := s.fhir[key]; ok {
+		version = fhirVersion(existing) + 1
+		status = http.StatusOK
+	}
+	s.writeFHIR(w, key, id, resource, version, status)
+}
+
+func (s *Server) writeFHIR(w http.ResponseWriter, key, id string, resource map[string]interface{}, version, status int) {
+	resource["id"] = id
+	resource["meta"] = map[string]interface{}{
+		"versionId":   strconv.Itoa(version),
+		"lastUpdated": time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+	}
+	data, _ := json.Marshal(resource)
+	s.fhir[key] = data
+	w.Header().Set("Content-Type", "application/fhir+json;fhirVersion=4.0")
+	w.Header().Set("ETag", fmt.Sprintf(`W/"%d"`, version))
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func fhirVersion(data json.RawMessage) int {
+	var resource struct {
+		Meta struct {
+			VersionID string `json:"versionId"`
+		} `json:"meta"`
+	}
+	_ = json.Unmarshal(data, &resource)
+	version, _ := strconv.Atoi(resource.Meta.VersionID)
+	return version
+}
+
+func (s *Server) createFHIR(w http.ResponseWriter, r *http.Request) {
+	s.storeFHIR(w, r, uuid.NewString(), http.StatusCreated)
+}
+
+func (s *Server) putFHIR(w http.ResponseWriter, r *http.Request) {
+	s.storeFHIR(w, r, r.PathValue("id"), http.StatusCreated)
+}
+
+func (s *Server) getFHIR(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.fhir[fhirKey(r, r.PathValue("id"))]
+	if !ok {
+		writeError(w, http.StatusNotFound, r.PathValue("type")+" not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/fhir+json;fhirVersion=4.0")
+	w.Header().Set("ETag", fmt.Sprintf(`W/"%d"`, fhirVersion(data)))
+	_, _ = w.Write(data)
+}
+
+func (s *Server) deleteFHIR(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := fhirKey(r, r.PathValue("id"))
+	if _, ok := s.fhir[key]; !ok {
+		writeError(w, http.StatusNotFound, r.PathValue("type")+" not found")
+		return
+	}
+	delete(s.fhir, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// patchFHIR applies the add, replace and remove operations of a JSON Patch
+func (s *Server) patchFHIR(w http.ResponseWriter, r *http.Request) {
+	var operations []struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	if err := readJSON(r, &operations); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	key := fhirKey(r, id)
+	data, ok := s.fhir[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, r.PathValue("type")+" not found")
+		return
+	}
+	var resource interface{}
+	_ = json.Unmarshal(data, &resource)
+	for _, op := range operations {
+		var err error
+		resource, err = applyPatch(resource, strings.Split(strings.TrimPrefix(op.Path, "/"), "/"), op.Op, op.Value)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
+	s.writeFHIR(w, key, id, resource.(map[string]interface{}), fhirVersion(data)+1, http.StatusOK)
+}
+
+func applyPatch(doc interface{}, path []string, op string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		if op == "remove" {
+			return nil, nil
+		}
+		return value, nil
+	}
+	head := strings.ReplaceAll(strings.ReplaceAll(path[0], "~1", "/"), "~0", "~")
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 && op == "remove" {
+			delete(node, head)
+			return node, nil
+		}
+		child, err := applyPatch(node[head], path[1:], op, value)
+		if err != nil {
+			return nil, err
+		}
+		node[head] = child
+		return node, nil
+	case []interface{}:
+		if head == "-" && len(path) == 1 && op == "add" {
+			return append(node, value), nil
+		}
+		i, err := strconv.Atoi(head)
+		if err != nil || i < 0 || i > len(node) {
+			return nil, fmt.Errorf("invalid array index %q", head)
+		}
+		if len(path) == 1 {
+			switch op {
+			case "remove":
+				return append(node[:i], node[i+1:]...), nil
+			case "add":
+				node = append(node[:i], append([]interface{}{value}, node[i:]...)...)
+				return node, nil
+			}
+		}
+		if i == len(node) {
+			return nil, fmt.Errorf("invalid array index %q", head)
+		}
+		child, err := applyPatch(node[i], path[1:], op, value)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+		return node, nil
+	case nil:
+		if op == "remove" {
+			return nil, fmt.Errorf("path not found")
+		}
+		child, err := applyPatch(nil, path[1:], op, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{head: child}, nil
+	}
+	return nil, fmt.Errorf("cannot patch %q", head)
+}
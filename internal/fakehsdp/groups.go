@@ -0,0 +1,365 @@
+package fakehsdp
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	memberTypeUser    = "USER"
+	memberTypeService = "SERVICE"
+	memberTypeDevice  = "DEVICE"
+)
+
+type group struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	Description          string `json:"description,omitempty"`
+	ManagingOrganization string `json:"managingOrganization"`
+
+	version int
+	roles   []string
+	members map[string][]string // member type -> IDs
+}
+
+func (g *group) etag() string {
+	return `W/"` + strconv.Itoa(g.version) + `"`
+}
+
+func (g *group) resource() map[string]interface{} {
+	return map[string]interface{}{
+		"_id":              g.ID,
+		"resourceType":     "Group",
+		"groupName":        g.Name,
+		"orgId":            g.ManagingOrganization,
+		"groupDescription": g.Description,
+	}
+}
+
+func (g *group) addMembers(memberType string, ids ...string) {
+	for _, id := range ids {
+		g.members[memberType] = appendUnique(g.members[memberType], id)
+	}
+	g.version++
+}
+
+func (g *group) removeMembers(memberType string, ids ...string) {
+	var kept []string
+	for _, id := range g.members[memberType] {
+		if !containsString(ids, id) {
+			kept = append(kept, id)
+		}
+	}
+	g.members[memberType] = kept
+	g.version++
+}
+
+// GroupMembers returns the IDs of the members of the given type (USER,
+// SERVICE or DEVICE) in a group, in the order they were added
+func (s *Server) GroupMembers(groupID, memberType string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[groupID]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), g.members[memberType]...)
+}
+
+// AddGroupMembers adds members of the given type to a group, bypassing the API
+func (s *Server) AddGroupMembers(groupID, memberType string, ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.groups[groupID]; ok {
+		g.addMembers(memberType, ids...)
+	}
+}
+
+// RemoveGroupMembers removes members of the given type from a group, bypassing the API
+func (s *Server) RemoveGroupMembers(groupID, memberType string, ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.groups[groupID]; ok {
+		g.removeMembers(memberType, ids...)
+	}
+}
+
+func (s *Server) registerGroups(mux *http.ServeMux) {
+	mux.HandleFunc("POST /authorize/identity/Group", s.authorized(s.createGroup))
+	mux.HandleFunc("GET /authorize/identity/Group", s.authorized(s.searchGroups))
+	mux.HandleFunc("GET /authorize/identity/Group/{id}", s.authorized(s.getGroup))
+	mux.HandleFunc("PUT /authorize/identity/Group/{id}", s.authorized(s.updateGroup))
+	mux.HandleFunc("DELETE /authorize/identity/Group/{id}", s.authorized(s.deleteGroup))
+	mux.HandleFunc("POST /authorize/identity/Group/{id}/{action}", s.authorized(s.groupAction))
+	mux.HandleFunc("GET /authorize/scim/v2/Groups/{id}", s.authorized(s.getSCIMGroup))
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	var g group
+	if err := readJSON(r, &g); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[g.ManagingOrganization]; !ok {
+		writeError(w, http.StatusBadRequest, "managing organization not found")
+		return
+	}
+	for _, existing := range s.groups {
+		if existing.Name == g.Name && existing.ManagingOrganization == g.ManagingOrganization {
+			writeError(w, http.StatusConflict, "group already exists")
+			return
+		}
+	}
+	g.ID = uuid.NewString()
+	g.version = 1
+	g.members = make(map[string][]string)
+	s.groups[g.ID] = &g
+	w.Header().Set("ETag", g.etag())
+	writeJSON(w, http.StatusCreated, g)
+}
+
+func (s *Server) searchGroups(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []map[string]interface{}
+	for _, g := range s.sortedGroups() {
+		if id := q.Get("_id"); id != "" && g.ID != id {
+			continue
+		}
+		if orgID := q.Get("orgID"); orgID != "" && g.ManagingOrganization != orgID {
+			continue
+		}
+		if name := q.Get("name"); name != "" && g.Name != name {
+			continue
+		}
+		if memberID := q.Get("memberId"); memberID != "" && !containsString(g.members[q.Get("memberType")], memberID) {
+			continue
+		}
+		entries = append(entries, map[string]interface{}{"resource": g.resource()})
+	}
+	writeJSON(w, http.StatusOK, bundle(entries))
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	w.Header().Set("ETag", g.etag())
+	writeJSON(w, http.StatusOK, g)
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var update struct {
+		Description string `json:"description"`
+	}
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	g.Description = update.Description
+	g.version++
+	w.Header().Set("ETag", g.etag())
+	writeJSON(w, http.StatusOK, g)
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	g, ok := s.groups[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	for _, members := range g.members {
+		if len(members) > 0 {
+			writeError(w, http.StatusConflict, "group still has members")
+			return
+		}
+	}
+	delete(s.groups, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) groupAction(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MemberType string   `json:"memberType"`
+		Value      []string `json:"value"`
+		Roles      []string `json:"roles"`
+		Parameter  []struct {
+			Name       string `json:"name"`
+			References []struct {
+				Reference string `json:"reference"`
+			} `json:"references"`
+		} `json:"parameter"`
+	}
+	if err := readJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	var users []string
+	for _, p := range body.Parameter {
+		for _, ref := range p.References {
+			users = append(users, ref.Reference)
+		}
+	}
+	switch action := r.PathValue("action"); action {
+	case "$add-members":
+		for _, id := range users {
+			if _, ok := s.users[id]; !ok {
+				writeError(w, http.StatusUnprocessableEntity, "user not found: "+id)
+				return
+			}
+		}
+		g.addMembers(memberTypeUser, users...)
+	case "$remove-members":
+		g.removeMembers(memberTypeUser, users...)
+	case "$assign", "$remove":
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != g.etag() {
+			writeError(w, http.StatusPreconditionFailed, "version mismatch")
+			return
+		}
+		if body.MemberType != memberTypeService && body.MemberType != memberTypeDevice {
+			writeError(w, http.StatusBadRequest, "invalid memberType: "+body.MemberType)
+			return
+		}
+		if action == "$assign" {
+			g.addMembers(body.MemberType, body.Value...)
+		} else {
+			g.removeMembers(body.MemberType, body.Value...)
+		}
+	case "$assign-role":
+		for _, id := range body.Roles {
+			if _, ok := s.roles[id]; !ok {
+				writeError(w, http.StatusUnprocessableEntity, "role not found: "+id)
+				return
+			}
+			g.roles = appendUnique(g.roles, id)
+		}
+	case "$remove-role":
+		var kept []string
+		for _, id := range g.roles {
+			if !containsString(body.Roles, id) {
+				kept = append(kept, id)
+			}
+		}
+		g.roles = kept
+	default:
+		writeError(w, http.StatusNotFound, "unsupported action: "+action)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resourceType": "OperationOutcome",
+		"issue": []map[string]string{
+			{"severity": "information", "code": "informational", "diagnostics": "success"},
+		},
+	})
+}
+
+// getSCIMGroup pages through members like IAM does: groupMembersStartIndex
+// is the 1-based index of the first member returned
+func (s *Server) getSCIMGroup(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	memberType := q.Get("includeGroupMembersType")
+	if memberType == "" {
+		memberType = memberTypeUser
+	}
+	count, _ := strconv.Atoi(q.Get("groupMembersCount"))
+	if count <= 0 || count > 100 {
+		count = 100
+	}
+	startIndex, _ := strconv.Atoi(q.Get("groupMembersStartIndex"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	members := g.members[memberType]
+	start := startIndex - 1
+	if start > len(members) {
+		start = len(members)
+	}
+	end := start + count
+	if end > len(members) {
+		end = len(members)
+	}
+	resources := make([]map[string]interface{}, 0, end-start)
+	for _, id := range members[start:end] {
+		resources = append(resources, map[string]interface{}{"id": id})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"id":          g.ID,
+		"displayName": g.Name,
+		"urn:ietf:params:scim:schemas:extension:philips:hsdp:2.0:Group": map[string]interface{}{
+			"description":  g.Description,
+			"organization": map[string]string{"value": g.ManagingOrganization},
+			"groupMembers": map[string]interface{}{
+				"schemas":      []string{"urn:ietf:params:scim:api:messages:2.0:ListResponse"},
+				"totalResults": len(members),
+				"startIndex":   startIndex,
+				"itemsPerPage": count,
+				"Resources":    resources,
+			},
+		},
+	})
+}
+
+func (s *Server) sortedGroups() []*group {
+	groups := make([]*group, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+func bundle(entries []map[string]interface{}) map[string]interface{} {
+	if entries == nil {
+		entries = []map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"resourceType": "Bundle",
+		"type":         "searchset",
+		"total":        len(entries),
+		"entry":        entries,
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package fakehsdp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const tokenLifetime = 1800
+
+func (s *Server) registerIAM(mux *http.ServeMux) {
+	mux.HandleFunc("POST /authorize/oauth2/token", s.handleToken)
+	mux.HandleFunc("POST /authorize/oauth2/introspect", s.handleIntrospect)
+	mux.HandleFunc("POST /authorize/oauth2/revoke", s.handleRevoke)
+}
+
+// handleToken implements the password, refresh_token, client_credentials and
+// JWT bearer grants. JWT assertions are matched on their issuer only, the
+// signature is not verified.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var principalID string
+	switch grant := r.PostForm.Get("grant_type"); grant {
+	case "password":
+		if !s.validClient(r) {
+			writeError(w, http.StatusUnauthorized, "invalid client credentials")
+			return
+		}
+		u := s.userByLogin(r.PostForm.Get("username"))
+		if u == nil || u.Password != r.PostForm.Get("password") {
+			writeError(w, http.StatusUnauthorized, "invalid user credentials")
+			return
+		}
+		if u.Disabled || u.Locked {
+			writeError(w, http.StatusUnauthorized, "account disabled or locked")
+			return
+		}
+		principalID = u.ID
+	case "refresh_token":
+		if !s.validClient(r) {
+			writeError(w, http.StatusUnauthorized, "invalid client credentials")
+			return
+		}
+		id, ok := s.tokens[r.PostForm.Get("refresh_token")]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		principalID = id
+	case "client_credentials":
+		if !s.validClient(r) {
+			writeError(w, http.StatusUnauthorized, "invalid client credentials")
+			return
+		}
+		principalID, _, _ = r.BasicAuth()
+	case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+		svc := s.serviceByServiceID(jwtIssuer(r.PostForm.Get("assertion")))
+		if svc == nil {
+			writeError(w, http.StatusUnauthorized, "unknown service identity")
+			return
+		}
+		principalID = svc["id"].(string)
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported grant_type: "+grant)
+		return
+	}
+	accessToken := uuid.NewString()
+	refreshToken := uuid.NewString()
+	s.tokens[accessToken] = principalID
+	s.tokens[refreshToken] = principalID
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    tokenLifetime,
+		"token_type":    "Bearer",
+		"scope":         "auth_iam_organization auth_iam_introspect mail openid profile cn",
+	})
+}
+
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	principalID, ok := s.tokens[r.PostForm.Get("token")]
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+	orgCtx := r.PostForm.Get("org_ctx")
+	username := principalID
+	identityType := "user"
+	managingOrg := s.RootOrgID
+	if u, ok := s.users[principalID]; ok {
+		username = u.LoginID
+		managingOrg = u.ManagingOrganization
+	} else if svc := s.identityByID("Service", principalID); svc != nil {
+		username = svc["serviceId"].(string)
+		identityType = "service"
+		managingOrg, _ = svc["organizationId"].(string)
+	}
+
+	var orgList []map[string]interface{}
+	orgIDs := make([]string, 0, len(s.orgs))
+	for id := range s.orgs {
+		orgIDs = append(orgIDs, id)
+	}
+	sort.Strings(orgIDs)
+	for _, orgID := range orgIDs {
+		if orgCtx != "" && orgCtx != orgID {
+			continue
+		}
+		grants := s.grants(principalID, orgID)
+		if len(grants) == 0 {
+			continue
+		}
+		var groups, roles, permissions []string
+		for _, g := range grants {
+			groups = appendUnique(groups, g.Group)
+			roles = appendUnique(roles, g.Role)
+			permissions = appendUnique(permissions, g.Permission)
+		}
+		orgList = append(orgList, map[string]interface{}{
+			"organizationId":       orgID,
+			"organizationName":     s.orgs[orgID].Name,
+			"permissions":          permissions,
+			"effectivePermissions": permissions,
+			"groups":               groups,
+			"roles":                roles,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"active":        true,
+		"scope":         "auth_iam_organization auth_iam_introspect",
+		"username":      username,
+		"sub":           principalID,
+		"exp":           time.Now().Add(tokenLifetime * time.Second).Unix(),
+		"client_id":     ClientID,
+		"token_type":    "Bearer",
+		"identity_type": identityType,
+		"organizations": map[string]interface{}{
+			"managingOrganization": managingOrg,
+			"organizationList":     orgList,
+		},
+	})
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	delete(s.tokens, r.PostForm.Get("token"))
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) validClient(r *http.Request) bool {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	if id == ClientID && secret == ClientPassword {
+		return true
+	}
+	client := s.identityByField("Client", "clientId", id)
+	return client != nil && client["password"] == secret
+}
+
+// jwtIssuer extracts the iss claim from a JWT without verifying it
+func jwtIssuer(assertion string) string {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Issuer
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,456 @@
+package fakehsdp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// collection holds schemaless documents keyed by ID
+type collection struct {
+	docs map[string]map[string]interface{}
+}
+
+func newCollection() *collection {
+	return &collection{docs: make(map[string]map[string]interface{})}
+}
+
+func (c *collection) sorted() []map[string]interface{} {
+	ids := make([]string, 0, len(c.docs))
+	for id := range c.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	docs := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		docs = append(docs, c.docs[id])
+	}
+	return docs
+}
+
+// secretFields are accepted on write but never returned by IAM
+var secretFields = []string{"password", "privateKey"}
+
+// defaultPageSize is the number of entries IAM returns when a search does not set _count
+const defaultPageSize = 100
+
+// identityKinds lists the IAM identity resources served by the generic handlers
+var identityKinds = []string{"Service", "Device", "Proposition", "Application", "Client"}
+
+func (s *Server) identity(kind string) *collection {
+	c, ok := s.identities[kind]
+	if !ok {
+		c = newCollection()
+		s.identities[kind] = c
+	}
+	return c
+}
+
+func (s *Server) identityByID(kind, id string) map[string]interface{} {
+	return s.identity(kind).docs[id]
+}
+
+func (s *Server) identityByField(kind, field, value string) map[string]interface{} {
+	for _, doc := range s.identity(kind).sorted() {
+		if v, _ := doc[field].(string); v == value {
+			return doc
+		}
+	}
+	return nil
+}
+
+func (s *Server) serviceByServiceID(serviceID string) map[string]interface{} {
+	if serviceID == "" {
+		return nil
+	}
+	return s.identityByField("Service", "serviceId", serviceID)
+}
+
+// AddService seeds a service identity in orgID and returns its ID, service ID
+// and PEM encoded private key
+func (s *Server) AddService(name, orgID string) (id, serviceID, privateKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := map[string]interface{}{
+		"name":           name,
+		"organizationId": orgID,
+		"applicationId":  uuid.NewString(),
+	}
+	s.initService(doc)
+	s.identity("Service").docs[doc["id"].(string)] = doc
+	return doc["id"].(string), doc["serviceId"].(string), doc["privateKey"].(string)
+}
+
+// AddDevice seeds a device identity in orgID and returns its ID
+func (s *Server) AddDevice(loginID, orgID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.NewString()
+	s.identity("Device").docs[id] = map[string]interface{}{
+		"id":             id,
+		"loginId":        loginID,
+		"organizationId": orgID,
+		"isActive":       true,
+	}
+	return id
+}
+
+// ServiceCertificate returns the PEM encoded certificate installed on a service
+func (s *Server) ServiceCertificate(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := s.identityByID("Service", id)
+	if doc == nil {
+		return ""
+	}
+	certPEM, _ := doc["certificate"].(string)
+	return certPEM
+}
+
+// ClientPassword returns the current password of a client
+func (s *Server) ClientPassword(id string) string {
+	return s.identityPassword("Client", id)
+}
+
+// DevicePassword returns the current password of a device
+func (s *Server) DevicePassword(id string) string {
+	return s.identityPassword("Device", id)
+}
+
+func (s *Server) identityPassword(kind, id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := s.identityByID(kind, id)
+	if doc == nil {
+		return ""
+	}
+	password, _ := doc["password"].(string)
+	return password
+}
+
+func (s *Server) initService(doc map[string]interface{}) {
+	id := uuid.NewString()
+	doc["id"] = id
+	doc["serviceId"] = fmt.Sprintf("%s@%s.fake.hsdp.io", doc["name"], id[:8])
+	doc["privateKey"] = generatePrivateKey()
+	validity := 12
+	if v, ok := doc["validity"].(float64); ok && v > 0 {
+		validity = int(v)
+	}
+	doc["expiresOn"] = time.Now().UTC().AddDate(0, validity, 0).Format(time.RFC3339)
+	if _, ok := doc["scopes"]; !ok {
+		doc["scopes"] = []interface{}{"openid"}
+	}
+	if _, ok := doc["defaultScopes"]; !ok {
+		doc["defaultScopes"] = []interface{}{"openid"}
+	}
+}
+
+func generatePrivateKey() string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+}
+
+func (s *Server) registerIdentities(mux *http.ServeMux) {
+	for _, kind := range identityKinds {
+		kind := kind
+		base := "/authorize/identity/" + kind
+		mux.HandleFunc("POST "+base, s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.createIdentity(w, r, kind)
+		}))
+		mux.HandleFunc("GET "+base, s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.searchIdentities(w, r, kind)
+		}))
+		mux.HandleFunc("PUT "+base+"/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.updateIdentity(w, r, kind)
+		}))
+		mux.HandleFunc("DELETE "+base+"/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.deleteIdentity(w, r, kind, http.StatusNoContent)
+		}))
+		mux.HandleFunc("POST "+base+"/{id}/{action}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.identityAction(w, r, kind)
+		}))
+		mux.HandleFunc("PUT "+base+"/{id}/{action}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.identityAction(w, r, kind)
+		}))
+	}
+	for _, kind := range []string{"Proposition", "Application"} {
+		kind := kind
+		base := "/authorize/scim/v2/" + kind + "s"
+		mux.HandleFunc("DELETE "+base+"/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.deleteIdentity(w, r, kind, http.StatusAccepted)
+		}))
+		mux.HandleFunc("GET "+base+"/{id}/deleteStatus", s.authorized(s.organizationDeleteStatus))
+	}
+}
+
+func (s *Server) createIdentity(w http.ResponseWriter, r *http.Request, kind string) {
+	doc := map[string]interface{}{}
+	if err := readJSON(r, &doc); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.identity(kind)
+	for _, existing := range c.docs {
+		if sameIdentity(kind, existing, doc) {
+			writeError(w, http.StatusConflict, kind+" already exists")
+			return
+		}
+	}
+	switch kind {
+	case "Service":
+		s.initService(doc)
+		c.docs[doc["id"].(string)] = doc
+		// The private key is only ever returned on creation
+		writeJSON(w, http.StatusCreated, doc)
+		return
+	case "Device":
+		doc["registrationDate"] = time.Now().UTC().Format(time.RFC3339)
+	case "Client":
+		if _, ok := doc["realms"]; !ok {
+			doc["realms"] = []interface{}{"root"}
+		}
+	}
+	id := uuid.NewString()
+	doc["id"] = id
+	c.docs[id] = doc
+	w.Header().Set("Location", "/authorize/identity/"+kind+"/"+id)
+	writeJSON(w, http.StatusCreated, redact(doc))
+}
+
+// sameIdentity reports whether two documents would collide on IAM's uniqueness constraints
+func sameIdentity(kind string, a, b map[string]interface{}) bool {
+	switch kind {
+	case "Device":
+		return a["loginId"] == b["loginId"]
+	case "Client":
+		return a["clientId"] == b["clientId"]
+	case "Proposition":
+		return a["name"] == b["name"] && a["organizationId"] == b["organizationId"]
+	default:
+		return a["name"] == b["name"] && a["applicationId"] == b["applicationId"]
+	}
+}
+
+func (s *Server) searchIdentities(w http.ResponseWriter, r *http.Request, kind string) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var members []string
+	if groupID := q.Get("groupId"); groupID != "" {
+		if g, ok := s.groups[groupID]; ok {
+			members = g.members[strings.ToUpper(kind)]
+		}
+	}
+	entries := []map[string]interface{}{}
+	for _, doc := range s.identity(kind).sorted() {
+		if matches(doc, q, members) {
+			entries = append(entries, redact(doc))
+		}
+	}
+	total := len(entries)
+	count, err := strconv.Atoi(q.Get("_count"))
+	if err != nil || count < 1 {
+		count = defaultPageSize
+	}
+	page, _ := strconv.Atoi(q.Get("_page"))
+	if page < 1 {
+		page = 1
+	}
+	start := min((page-1)*count, total)
+	entries = entries[start:min(start+count, total)]
+	writeJSON(w, http.StatusOK, map[string]interface{}{"total": total, "entry": entries})
+}
+
+// matches applies the query parameters of an identity search to a document
+func matches(doc map[string]interface{}, q map[string][]string, members []string) bool {
+	for key, values := range q {
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+		want := values[0]
+		switch key {
+		case "_count", "_page", "profileType":
+			continue
+		case "groupId":
+			if !containsString(members, doc["id"].(string)) {
+				return false
+			}
+			continue
+		case "_id":
+			key = "id"
+		}
+		var value interface{} = doc
+		for _, part := range strings.Split(key, ".") {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[part]
+		}
+		if value == nil || fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func redact(doc map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		if !containsString(secretFields, k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func (s *Server) updateIdentity(w http.ResponseWriter, r *http.Request, kind string) {
+	update := map[string]interface{}{}
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := s.identityByID(kind, r.PathValue("id"))
+	if doc == nil {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	if kind == "Service" {
+		// Only the description and token lifetime of a service can be updated
+		doc["description"] = update["description"]
+		if lifetime, ok := update["accessTokenLifetime"]; ok {
+			doc["accessTokenLifetime"] = lifetime
+			doc["tokenValidity"] = lifetime
+		}
+	} else {
+		for k, v := range update {
+			switch k {
+			case "id", "loginId", "organizationId", "meta":
+				continue
+			case "password":
+				// Clients and devices take a new password through an update, the other kinds have a password action
+				if kind != "Client" && kind != "Device" {
+					continue
+				}
+			}
+			doc[k] = v
+		}
+	}
+	writeJSON(w, http.StatusOK, redact(doc))
+}
+
+func (s *Server) deleteIdentity(w http.ResponseWriter, r *http.Request, kind string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	c := s.identity(kind)
+	if _, ok := c.docs[id]; !ok {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	delete(c.docs, id)
+	memberType := strings.ToUpper(kind)
+	for _, g := range s.groups {
+		if containsString(g.members[memberType], id) {
+			g.removeMembers(memberType, id)
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func (s *Server) identityAction(w http.ResponseWriter, r *http.Request, kind string) {
+	body := map[string]interface{}{}
+	if err := readJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := s.identityByID(kind, r.PathValue("id"))
+	if doc == nil {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	switch action := r.PathValue("action"); action {
+	case "$scopes":
+		for _, field := range []string{"scopes", "defaultScopes"} {
+			current := toStrings(doc[field])
+			for _, scope := range toStrings(body[field]) {
+				if body["action"] == "remove" {
+					var kept []string
+					for _, c := range current {
+						if c != scope {
+							kept = append(kept, c)
+						}
+					}
+					current = kept
+				} else {
+					current = appendUnique(current, scope)
+				}
+			}
+			doc[field] = current
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "$update-certificate":
+		certPEM, _ := body["certificate"].(string)
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			writeError(w, http.StatusBadRequest, "invalid certificate")
+			return
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// The service credentials now expire with the certificate
+		doc["certificate"] = certPEM
+		doc["expiresOn"] = cert.NotAfter.UTC().Format(time.RFC3339)
+		writeJSON(w, http.StatusOK, redact(doc))
+	case "$change-password":
+		if doc["password"] != body["oldPassword"] {
+			writeError(w, http.StatusUnprocessableEntity, "old password does not match")
+			return
+		}
+		doc["password"] = body["newPassword"]
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func toStrings(v interface{}) []string {
+	var out []string
+	switch list := v.(type) {
+	case []string:
+		out = append(out, list...)
+	case []interface{}:
+		for _, e := range list {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package fakehsdp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// mdmPrefix is the path the fake serves Connect MDM from, see ProviderConfig
+const mdmPrefix = "/connect/mdm"
+
+// mdmKind returns the document collection of an MDM resource type
+func (s *Server) mdmKind(kind string) *collection {
+	return s.document("mdm/" + kind)
+}
+
+func (s *Server) registerMDM(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+mdmPrefix+"/{kind}", s.authorized(s.createMDM))
+	mux.HandleFunc("GET "+mdmPrefix+"/{kind}", s.authorized(s.searchMDM))
+	mux.HandleFunc("GET "+mdmPrefix+"/{kind}/{id}", s.authorized(s.getMDM))
+	mux.HandleFunc("PUT "+mdmPrefix+"/{kind}/{id}", s.authorized(s.updateMDM))
+	mux.HandleFunc("DELETE "+mdmPrefix+"/{kind}/{id}", s.authorized(s.deleteMDM))
+}
+
+func mdmMeta(version int) map[string]interface{} {
+	return map[string]interface{}{
+		"versionId":   strconv.Itoa(version),
+		"lastUpdated": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func (s *Server) createMDM(w http.ResponseWriter, r *http.Request) {
+	doc := map[string]interface{}{}
+	if err := readJSON(r, &doc); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	kind := r.PathValue("kind")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.NewString()
+	doc["id"] = id
+	doc["resourceType"] = kind
+	doc["meta"] = mdmMeta(1)
+	s.mdmKind(kind).docs[id] = doc
+	w.Header().Set("Location", mdmPrefix+"/"+kind+"/"+id)
+	w.Header().Set("ETag", `W/"1"`)
+	writeJSON(w, http.StatusCreated, doc)
+}
+
+func (s *Server) searchMDM(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []map[string]interface{}
+	for _, doc := range s.mdmKind(r.PathValue("kind")).sorted() {
+		if matches(doc, r.URL.Query(), nil) {
+			entries = append(entries, map[string]interface{}{"resource": doc})
+		}
+	}
+	writeJSON(w, http.StatusOK, bundle(entries))
+}
+
+func (s *Server) getMDM(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.mdmKind(r.PathValue("kind")).docs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, r.PathValue("kind")+" not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (s *Server) updateMDM(w http.ResponseWriter, r *http.Request) {
+	update := map[string]interface{}{}
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	kind, id := r.PathValue("kind"), r.PathValue("id")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.mdmKind(kind)
+	doc, ok := c.docs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	version := 1
+	if m, ok := doc["meta"].(map[string]interface{}); ok {
+		version, _ = strconv.Atoi(m["versionId"].(string))
+	}
+	update["id"] = id
+	update["resourceType"] = kind
+	update["meta"] = mdmMeta(version + 1)
+	c.docs[id] = update
+	writeJSON(w, http.StatusOK, update)
+}
+
+func (s *Server) deleteMDM(w http.ResponseWriter, r *http.Request) {
+	kind, id := r.PathValue("kind"), r.PathValue("id")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.mdmKind(kind)
+	if _, ok := c.docs[id]; !ok {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	delete(c.docs, id)
+	w.WriteHeader(http.StatusNoContent)
+}
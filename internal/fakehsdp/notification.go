@@ -0,0 +1,133 @@
+package fakehsdp
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+var notificationKinds = []string{"Producer", "Topic", "Subscriber", "Subscription"}
+
+func (s *Server) document(kind string) *collection {
+	c, ok := s.documents[kind]
+	if !ok {
+		c = newCollection()
+		s.documents[kind] = c
+	}
+	return c
+}
+
+func (s *Server) registerNotification(mux *http.ServeMux) {
+	for _, kind := range notificationKinds {
+		kind := kind
+		base := "/core/notification/" + kind
+		mux.HandleFunc("POST "+base, s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.createNotification(w, r, kind)
+		}))
+		mux.HandleFunc("GET "+base, s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.searchNotifications(w, r, kind)
+		}))
+		mux.HandleFunc("PUT "+base+"/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.updateNotification(w, r, kind)
+		}))
+		mux.HandleFunc("DELETE "+base+"/{id}", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+			s.deleteNotification(w, r, kind)
+		}))
+	}
+	mux.HandleFunc("POST /core/notification/Subscription/_confirm", s.authorized(s.confirmSubscription))
+	mux.HandleFunc("POST /core/notification/Publish", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"messageId": uuid.NewString()})
+	}))
+}
+
+func (s *Server) createNotification(w http.ResponseWriter, r *http.Request, kind string) {
+	doc := map[string]interface{}{}
+	if err := readJSON(r, &doc); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.NewString()
+	doc["_id"] = id
+	doc["resourceType"] = kind
+	s.document(kind).docs[id] = doc
+	writeJSON(w, http.StatusCreated, doc)
+}
+
+func (s *Server) searchNotifications(w http.ResponseWriter, r *http.Request, kind string) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := q.Get("_id")
+	q.Del("_id") // notification documents carry their ID in _id
+	entries := []map[string]interface{}{}
+	for _, doc := range s.document(kind).sorted() {
+		if id != "" && doc["_id"] != id {
+			continue
+		}
+		if matches(doc, q, nil) {
+			entries = append(entries, doc)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resourceType": "bundle",
+		"type":         "searchset",
+		"total":        len(entries),
+		"entry":        entries,
+	})
+}
+
+func (s *Server) updateNotification(w http.ResponseWriter, r *http.Request, kind string) {
+	update := map[string]interface{}{}
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	c := s.document(kind)
+	if _, ok := c.docs[id]; !ok {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	update["_id"] = id
+	update["resourceType"] = kind
+	c.docs[id] = update
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteNotification(w http.ResponseWriter, r *http.Request, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	c := s.document(kind)
+	if _, ok := c.docs[id]; !ok {
+		writeError(w, http.StatusNotFound, kind+" not found")
+		return
+	}
+	delete(c.docs, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) confirmSubscription(w http.ResponseWriter, r *http.Request) {
+	var confirm struct {
+		TopicID  string `json:"topicId"`
+		Endpoint string `json:"endpoint"`
+	}
+	if err := readJSON(r, &confirm); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.document("Subscription").sorted() {
+		if doc["topicId"] == confirm.TopicID && doc["subscriptionEndpoint"] == confirm.Endpoint {
+			doc["subscriptionStatus"] = "Confirmed"
+			writeJSON(w, http.StatusCreated, doc)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "subscription not found")
+}
@@ -0,0 +1,235 @@
+package fakehsdp
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type organization struct {
+	Schemas           []string               `json:"schemas"`
+	ID                string                 `json:"id"`
+	ExternalID        string                 `json:"externalId,omitempty"`
+	Name              string                 `json:"name"`
+	DisplayName       string                 `json:"displayName,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	Parent            map[string]interface{} `json:"parent,omitempty"`
+	Type              string                 `json:"type,omitempty"`
+	Active            bool                   `json:"active,omitempty"`
+	InheritProperties bool                   `json:"inheritProperties,omitempty"`
+	Address           map[string]interface{} `json:"address,omitempty"`
+	Meta              *meta                  `json:"meta,omitempty"`
+}
+
+type meta struct {
+	ResourceType string    `json:"resourceType,omitempty"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Version      string    `json:"version,omitempty"`
+}
+
+func newMeta(resourceType string) *meta {
+	now := time.Now().UTC()
+	return &meta{
+		ResourceType: resourceType,
+		Created:      now,
+		LastModified: now,
+		Version:      `W/"1"`,
+	}
+}
+
+// bump increments the weak ETag version after a modification
+func (m *meta) bump() {
+	var version int
+	_, _ = fmt.Sscanf(m.Version, `W/"%d"`, &version)
+	m.Version = fmt.Sprintf(`W/"%d"`, version+1)
+	m.LastModified = time.Now().UTC()
+}
+
+var scimFilter = regexp.MustCompile(`^([a-zA-Z.]+) eq "(.*)"$`)
+
+// AddOrganization seeds a sub organization of parentID and returns its ID
+func (s *Server) AddOrganization(name, parentID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org := &organization{
+		ID:     uuid.NewString(),
+		Name:   name,
+		Active: true,
+		Parent: map[string]interface{}{"value": parentID},
+		Meta:   newMeta("Organization"),
+	}
+	s.orgs[org.ID] = org
+	return org.ID
+}
+
+// DeleteOrganization removes an organization without going through the API,
+// simulating a deletion made outside Terraform
+func (s *Server) DeleteOrganization(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.orgs, id)
+}
+
+func (s *Server) registerOrganizations(mux *http.ServeMux) {
+	mux.HandleFunc("POST /authorize/scim/v2/Organizations", s.authorized(s.createOrganization))
+	mux.HandleFunc("GET /authorize/scim/v2/Organizations", s.authorized(s.searchOrganizations))
+	mux.HandleFunc("GET /authorize/scim/v2/Organizations/{id}", s.authorized(s.getOrganization))
+	mux.HandleFunc("PUT /authorize/scim/v2/Organizations/{id}", s.authorized(s.updateOrganization))
+	mux.HandleFunc("DELETE /authorize/scim/v2/Organizations/{id}", s.authorized(s.deleteOrganization))
+	mux.HandleFunc("GET /authorize/scim/v2/Organizations/{id}/deleteStatus", s.authorized(s.organizationDeleteStatus))
+}
+
+func (s *Server) createOrganization(w http.ResponseWriter, r *http.Request) {
+	var org organization
+	if err := readJSON(r, &org); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parentID, _ := org.Parent["value"].(string)
+	if _, ok := s.orgs[parentID]; !ok {
+		writeError(w, http.StatusBadRequest, "parent organization not found")
+		return
+	}
+	for _, o := range s.orgs {
+		if o.Name == org.Name {
+			writeError(w, http.StatusConflict, "organization already exists")
+			return
+		}
+	}
+	org.ID = uuid.NewString()
+	org.Active = true
+	org.Meta = newMeta("Organization")
+	s.orgs[org.ID] = &org
+	writeJSON(w, http.StatusCreated, org)
+}
+
+func (s *Server) searchOrganizations(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var field, value string
+	if m := scimFilter.FindStringSubmatch(r.URL.Query().Get("filter")); m != nil {
+		field, value = m[1], m[2]
+	}
+	var ids []string
+	for _, org := range s.orgs {
+		parentID, _ := org.Parent["value"].(string)
+		switch field {
+		case "id":
+			if org.ID != value {
+				continue
+			}
+		case "name":
+			if org.Name != value {
+				continue
+			}
+		case "parent.value":
+			if parentID != value {
+				continue
+			}
+		}
+		ids = append(ids, org.ID)
+	}
+	sort.Strings(ids)
+	total := len(ids)
+	start, count := scimPage(r, total)
+	ids = ids[start-1 : start-1+count]
+	resources := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if r.URL.Query().Get("attributes") == "id" {
+			resources = append(resources, map[string]string{"id": id})
+			continue
+		}
+		resources = append(resources, s.orgs[id])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas":      []string{"urn:ietf:params:scim:api:messages:2.0:ListResponse"},
+		"totalResults": total,
+		"startIndex":   start,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	})
+}
+
+// scimPage returns the 1-based start index and number of items of the
+// requested page, clamped to total
+func scimPage(r *http.Request, total int) (start, count int) {
+	start, _ = strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if start < 1 {
+		start = 1
+	}
+	if start > total+1 {
+		start = total + 1
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 0 || start-1+count > total {
+		count = total - (start - 1)
+	}
+	return start, count
+}
+
+func (s *Server) getOrganization(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, org)
+}
+
+func (s *Server) updateOrganization(w http.ResponseWriter, r *http.Request) {
+	var update organization
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && org.Meta != nil && ifMatch != org.Meta.Version {
+		writeError(w, http.StatusPreconditionFailed, "version mismatch")
+		return
+	}
+	update.ID = org.ID
+	update.Parent = org.Parent
+	update.Meta = org.Meta
+	if update.Meta == nil {
+		update.Meta = newMeta("Organization")
+	}
+	update.Meta.bump()
+	s.orgs[org.ID] = &update
+	writeJSON(w, http.StatusOK, update)
+}
+
+func (s *Server) deleteOrganization(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	if _, ok := s.orgs[id]; !ok {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+	delete(s.orgs, id)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) organizationDeleteStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas": []string{"urn:ietf:params:scim:schemas:core:philips:hsdp:2.0:OrganizationStatus"},
+		"id":      r.PathValue("id"),
+		"status":  "SUCCESS",
+	})
+}
@@ -0,0 +1,338 @@
+package fakehsdp
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// defaultPermissions is the permission catalog the fake starts with
+var defaultPermissions = []string{
+	"ALL.READ",
+	"ALL.WRITE",
+	"APPLICATION.READ",
+	"APPLICATION.WRITE",
+	"CLIENT.READ",
+	"CLIENT.WRITE",
+	"DEVICE.READ",
+	"DEVICE.WRITE",
+	"GROUP.READ",
+	"GROUP.WRITE",
+	"ORGANIZATION.READ",
+	"ORGANIZATION.WRITE",
+	"PASSWORDPOLICY.READ",
+	"PASSWORDPOLICY.WRITE",
+	"PERMISSION.READ",
+	"PROPOSITION.READ",
+	"PROPOSITION.WRITE",
+	"ROLE.READ",
+	"ROLE.WRITE",
+	"SERVICE.DELETE",
+	"SERVICE.READ",
+	"SERVICE.SCOPE",
+	"SERVICE.WRITE",
+	"USER.READ",
+	"USER.WRITE",
+	"CP-CONFIG.READ",
+	"NS_TOPIC.READ",
+	"NS_TOPIC.WRITE",
+	"NS_PRODUCER.READ",
+	"NS_PRODUCER.WRITE",
+	"NS_SUBSCRIBER.READ",
+	"NS_SUBSCRIBER.WRITE",
+	"NS_SUBSCRIPTION.READ",
+	"NS_SUBSCRIPTION.WRITE",
+}
+
+type permission struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+}
+
+type role struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	ManagingOrganization string `json:"managingOrganization"`
+
+	permissions []string
+}
+
+// grant is a single permission a principal holds in an organization
+type grant struct {
+	Group      string
+	Role       string
+	Permission string
+}
+
+// AddPermission adds a permission to the catalog
+func (s *Server) AddPermission(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addPermission(name)
+}
+
+func (s *Server) addPermission(name string) {
+	category := name
+	if i := strings.Index(name, "."); i > 0 {
+		category = name[:i]
+	}
+	s.permissions[name] = permission{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Description: "Permission " + name,
+		Category:    category,
+		Type:        "GLOBAL",
+	}
+}
+
+// RolePermissions returns the sorted permission names assigned to a role
+func (s *Server) RolePermissions(roleID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.roles[roleID]
+	if !ok {
+		return nil
+	}
+	permissions := append([]string(nil), r.permissions...)
+	sort.Strings(permissions)
+	return permissions
+}
+
+// grants computes the permissions a principal holds in orgID through its
+// group memberships. The seeded administrator holds every permission in
+// every organization.
+func (s *Server) grants(principalID, orgID string) []grant {
+	var grants []grant
+	if principalID == s.AdminID {
+		names := make([]string, 0, len(s.permissions))
+		for name := range s.permissions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			grants = append(grants, grant{Permission: name})
+		}
+		return grants
+	}
+	for _, g := range s.sortedGroups() {
+		if g.ManagingOrganization != orgID {
+			continue
+		}
+		var member bool
+		for _, ids := range g.members {
+			member = member || containsString(ids, principalID)
+		}
+		if !member {
+			continue
+		}
+		for _, roleID := range g.roles {
+			r, ok := s.roles[roleID]
+			if !ok {
+				continue
+			}
+			for _, p := range r.permissions {
+				grants = append(grants, grant{Group: g.Name, Role: r.Name, Permission: p})
+			}
+		}
+	}
+	return grants
+}
+
+func (s *Server) registerRoles(mux *http.ServeMux) {
+	mux.HandleFunc("POST /authorize/identity/Role", s.authorized(s.createRole))
+	mux.HandleFunc("GET /authorize/identity/Role", s.authorized(s.searchRoles))
+	mux.HandleFunc("GET /authorize/identity/Role/{id}", s.authorized(s.getRole))
+	mux.HandleFunc("DELETE /authorize/identity/Role/{id}", s.authorized(s.deleteRole))
+	mux.HandleFunc("POST /authorize/identity/Role/{id}/{action}", s.authorized(s.roleAction))
+	mux.HandleFunc("GET /authorize/identity/Role/{id}/{action}", s.authorized(s.listSharingPolicies))
+	mux.HandleFunc("GET /authorize/identity/Permission", s.authorized(s.searchPermissions))
+}
+
+func (s *Server) createRole(w http.ResponseWriter, r *http.Request) {
+	var ro role
+	if err := readJSON(r, &ro); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[ro.ManagingOrganization]; !ok {
+		writeError(w, http.StatusBadRequest, "managing organization not found")
+		return
+	}
+	for _, existing := range s.roles {
+		if existing.Name == ro.Name && existing.ManagingOrganization == ro.ManagingOrganization {
+			writeError(w, http.StatusConflict, "role already exists")
+			return
+		}
+	}
+	ro.ID = uuid.NewString()
+	s.roles[ro.ID] = &ro
+	writeJSON(w, http.StatusCreated, ro)
+}
+
+func (s *Server) searchRoles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var groupRoles []string
+	if groupID := q.Get("groupId"); groupID != "" {
+		g, ok := s.groups[groupID]
+		if !ok {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"total": 0, "entry": []role{}})
+			return
+		}
+		groupRoles = g.roles
+	}
+	entries := []role{}
+	for _, ro := range s.sortedRoles() {
+		if name := q.Get("name"); name != "" && ro.Name != name {
+			continue
+		}
+		if orgID := q.Get("organizationId"); orgID != "" && ro.ManagingOrganization != orgID {
+			continue
+		}
+		if roleID := q.Get("roleId"); roleID != "" && ro.ID != roleID {
+			continue
+		}
+		if q.Get("groupId") != "" && !containsString(groupRoles, ro.ID) {
+			continue
+		}
+		entries = append(entries, *ro)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"total": len(entries), "entry": entries})
+}
+
+func (s *Server) getRole(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ro, ok := s.roles[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "role not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, ro)
+}
+
+func (s *Server) deleteRole(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	if _, ok := s.roles[id]; !ok {
+		writeError(w, http.StatusNotFound, "role not found")
+		return
+	}
+	for _, g := range s.groups {
+		if containsString(g.roles, id) {
+			writeError(w, http.StatusConflict, "role is still assigned to group "+g.Name)
+			return
+		}
+	}
+	delete(s.roles, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) roleAction(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := readJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ro, ok := s.roles[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "role not found")
+		return
+	}
+	switch action := r.PathValue("action"); action {
+	case "$assign-permission":
+		for _, name := range body.Permissions {
+			if _, ok := s.permissions[name]; !ok {
+				writeError(w, http.StatusNotFound, "permission not found: "+name)
+				return
+			}
+		}
+		for _, name := range body.Permissions {
+			ro.permissions = appendUnique(ro.permissions, name)
+		}
+	case "$remove-permission":
+		var kept []string
+		for _, name := range ro.permissions {
+			if !containsString(body.Permissions, name) {
+				kept = append(kept, name)
+			}
+		}
+		ro.permissions = kept
+	default:
+		writeError(w, http.StatusNotFound, "unsupported action: "+action)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resourceType": "OperationOutcome",
+		"issue": []map[string]string{
+			{"severity": "information", "code": "informational", "diagnostics": "success"},
+		},
+	})
+}
+
+// listSharingPolicies answers $list-sharing-policies; the fake never shares roles
+func (s *Server) listSharingPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("action") != "$list-sharing-policies" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"total": 0, "entry": []interface{}{}})
+}
+
+func (s *Server) searchPermissions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	if roleID := q.Get("roleId"); roleID != "" {
+		ro, ok := s.roles[roleID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "role not found")
+			return
+		}
+		names = append(names, ro.permissions...)
+	} else {
+		for name := range s.permissions {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	entries := []permission{}
+	for _, name := range names {
+		p, ok := s.permissions[name]
+		if !ok {
+			continue
+		}
+		if n := q.Get("name"); n != "" && p.Name != n {
+			continue
+		}
+		if id := q.Get("_id"); id != "" && p.ID != id {
+			continue
+		}
+		entries = append(entries, p)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"total": len(entries), "entry": entries})
+}
+
+func (s *Server) sortedRoles() []*role {
+	roles := make([]*role, 0, len(s.roles))
+	for _, ro := range s.roles {
+		roles = append(roles, ro)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
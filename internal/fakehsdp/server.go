@@ -0,0 +1,197 @@
+// Package fakehsdp provides an in-process fake of the HSDP IAM, IDM,
+// Notification, CDR, Connect MDM and Cartel APIs. Point the provider at it
+// through iam_url, idm_url, notification_url, mdm_url and cartel_host to run
+// resource tests without a live tenant.
+package fakehsdp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AdminUsername is the login of the seeded organization administrator
+	AdminUsername = "fake-admin"
+	// AdminPassword is the password of the seeded organization administrator
+	AdminPassword = "FakeAdmin1!"
+	// ClientID is the OAuth2 client ID the fake accepts for user logins
+	ClientID = "fake-client"
+	// ClientPassword is the OAuth2 client password the fake accepts for user logins
+	ClientPassword = "fake-client-secret"
+)
+
+// Server is a fake HSDP endpoint backed by in-memory state
+type Server struct {
+	*httptest.Server
+
+	// RootOrgID is the ID of the seeded root organization
+	RootOrgID string
+	// AdminID is the user ID of the seeded organization administrator
+	AdminID string
+
+	mu sync.Mutex
+
+	tokens      map[string]string // access or refresh token -> principal ID
+	orgs        map[string]*organization
+	groups      map[string]*group
+	roles       map[string]*role
+	permissions map[string]permission
+	users       map[string]*user
+	identities  map[string]*collection
+	documents   map[string]*collection
+	fhir        map[string]json.RawMessage
+	instances   map[string]*instance
+	requests    map[string]int
+}
+
+// New starts a fake HSDP server seeded with a root organization and an
+// organization administrator. The server is closed when the test ends.
+func New(t testing.TB) *Server {
+	s := &Server{
+		RootOrgID:   uuid.NewString(),
+		AdminID:     uuid.NewString(),
+		tokens:      make(map[string]string),
+		orgs:        make(map[string]*organization),
+		groups:      make(map[string]*group),
+		roles:       make(map[string]*role),
+		permissions: make(map[string]permission),
+		users:       make(map[string]*user),
+		identities:  make(map[string]*collection),
+		documents:   make(map[string]*collection),
+		fhir:        make(map[string]json.RawMessage),
+		instances:   make(map[string]*instance),
+		requests:    make(map[string]int),
+	}
+	s.orgs[s.RootOrgID] = &organization{
+		ID:     s.RootOrgID,
+		Name:   "ROOT",
+		Active: true,
+		Meta:   newMeta("Organization"),
+	}
+	s.users[s.AdminID] = &user{
+		ID:                   s.AdminID,
+		LoginID:              AdminUsername,
+		Password:             AdminPassword,
+		EmailAddress:         AdminUsername + "@example.com",
+		ManagingOrganization: s.RootOrgID,
+	}
+	for _, name := range defaultPermissions {
+		s.addPermission(name)
+	}
+
+	mux := http.NewServeMux()
+	s.registerIAM(mux)
+	s.registerOrganizations(mux)
+	s.registerGroups(mux)
+	s.registerRoles(mux)
+	s.registerUsers(mux)
+	s.registerIdentities(mux)
+	s.registerNotification(mux)
+	s.registerMDM(mux)
+	s.registerCDR(mux)
+	s.registerCartel(mux)
+
+	s.Server = httptest.NewServer(s.countRequests(mux))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ProviderConfig returns a provider block which points all supported
+// services to the fake and logs in as the seeded administrator
+func (s *Server) ProviderConfig() string {
+	return fmt.Sprintf(`
+provider "hsdp" {
+  region             = "us-east"
+  environment        = "client-test"
+  iam_url            = "%[1]s"
+  idm_url            = "%[1]s"
+  notification_url   = "%[1]s"
+  mdm_url            = "%[1]s/connect/mdm"
+  oauth2_client_id   = "%[2]s"
+  oauth2_password    = "%[3]s"
+  org_admin_username = "%[4]s"
+  org_admin_password = "%[5]s"
+  shared_key         = "fake-shared-key"
+  secret_key         = "fake-secret-key"
+  cartel_host        = "%[6]s"
+  cartel_token       = "%[7]s"
+  cartel_secret      = "%[8]s"
+  cartel_no_tls      = true
+}
+`, s.URL, ClientID, ClientPassword, AdminUsername, AdminPassword,
+		strings.TrimPrefix(s.URL, "http://"), CartelToken, CartelSecret)
+}
+
+// FHIRStoreURL returns the CDR FHIR store endpoint for the given root organization
+func (s *Server) FHIRStoreURL(orgID string) string {
+	return s.URL + "/store/fhir/" + orgID
+}
+
+// Requests returns how many requests were received for the given method and path
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+func (s *Server) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.Method+" "+r.URL.Path]++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized wraps a handler and rejects requests without a token issued by the fake
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		_, ok := s.tokens[token]
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid or missing access token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"resourceType": "OperationOutcome",
+		"issue": []map[string]string{
+			{"severity": "error", "code": "processing", "diagnostics": message},
+		},
+		"responseCode":    fmt.Sprintf("%d", status),
+		"responseMessage": message,
+	})
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
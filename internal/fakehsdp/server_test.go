@@ -0,0 +1,164 @@
+package fakehsdp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIAMClient(t *testing.T, fake *fakehsdp.Server) *iam.Client {
+	t.Helper()
+	client, err := iam.NewClient(nil, &iam.Config{
+		Region:         "us-east",
+		Environment:    "client-test",
+		OAuth2ClientID: fakehsdp.ClientID,
+		OAuth2Secret:   fakehsdp.ClientPassword,
+		IAMURL:         fake.URL,
+		IDMURL:         fake.URL,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestLogin(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client := newIAMClient(t, fake)
+
+	assert.Error(t, client.Login(fakehsdp.AdminUsername, "wrong"))
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	introspect, _, err := client.Introspect()
+	require.NoError(t, err)
+	assert.True(t, introspect.Active)
+	assert.Equal(t, fakehsdp.AdminUsername, introspect.Username)
+	assert.Equal(t, fake.RootOrgID, introspect.Organizations.ManagingOrganization)
+}
+
+func TestServiceLogin(t *testing.T) {
+	fake := fakehsdp.New(t)
+	_, serviceID, privateKey := fake.AddService("svc", fake.RootOrgID)
+	client := newIAMClient(t, fake)
+
+	require.NoError(t, client.ServiceLogin(iam.Service{
+		ServiceID:  serviceID,
+		PrivateKey: privateKey,
+	}))
+	introspect, _, err := client.Introspect()
+	require.NoError(t, err)
+	assert.Equal(t, serviceID, introspect.Username)
+}
+
+func TestUnauthorized(t *testing.T) {
+	fake := fakehsdp.New(t)
+
+	resp, err := http.Get(fake.URL + "/authorize/identity/Group?name=x")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestGroupMembersPaging(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client := newIAMClient(t, fake)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	group, _, err := client.Groups.CreateGroup(iam.Group{
+		Name:                 "members",
+		ManagingOrganization: fake.RootOrgID,
+	})
+	require.NoError(t, err)
+
+	var users []string
+	for i := 0; i < 250; i++ {
+		users = append(users, fake.AddUser(fmt.Sprintf("user%03d", i), "Passw0rd!", fake.RootOrgID))
+	}
+	_, _, err = client.Groups.AddMembers(context.Background(), *group, users[:25]...)
+	require.NoError(t, err)
+	fake.AddGroupMembers(group.ID, "USER", users[25:]...)
+
+	var members []string
+	count := 100
+	for _, startIndex := range []int{1, 101, 201} {
+		scimGroup, _, err := client.Groups.SCIMGetGroupByID(group.ID, &iam.SCIMGetGroupOptions{
+			IncludeGroupMembersType: iam.String(iam.GroupMemberTypeUser),
+			GroupMembersCount:       &count,
+			GroupMembersStartIndex:  &startIndex,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 250, scimGroup.ExtensionGroup.GroupMembers.TotalResults)
+		for _, r := range scimGroup.ExtensionGroup.GroupMembers.Resources {
+			members = append(members, r.ID)
+		}
+	}
+	assert.Equal(t, users, members)
+	assert.Equal(t, 3, fake.Requests(http.MethodGet, "/authorize/scim/v2/Groups/"+group.ID))
+
+	_, _, err = client.Groups.RemoveMembers(context.Background(), *group, users...)
+	require.NoError(t, err)
+	assert.Empty(t, fake.GroupMembers(group.ID, "USER"))
+}
+
+func TestRolePermissions(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client := newIAMClient(t, fake)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	role, _, err := client.Roles.CreateRole("ROLE", "test role", fake.RootOrgID)
+	require.NoError(t, err)
+
+	_, _, err = client.Roles.AddRolePermission(*role, "GROUP.READ")
+	require.NoError(t, err)
+	_, resp, err := client.Roles.AddRolePermission(*role, "NO_SUCH.PERMISSION")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	permissions, _, err := client.Roles.GetRolePermissions(*role)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GROUP.READ"}, *permissions)
+	assert.Equal(t, []string{"GROUP.READ"}, fake.RolePermissions(role.ID))
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client := newIAMClient(t, fake)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	orgID := fake.AddOrganization("sub", fake.RootOrgID)
+	userID := fake.AddUser("alice", "Passw0rd!", orgID)
+	role, _, err := client.Roles.CreateRole("READER", "", orgID)
+	require.NoError(t, err)
+	_, _, err = client.Roles.AddRolePermission(*role, "USER.READ")
+	require.NoError(t, err)
+	group, _, err := client.Groups.CreateGroup(iam.Group{Name: "readers", ManagingOrganization: orgID})
+	require.NoError(t, err)
+	_, _, err = client.Groups.AssignRole(context.Background(), *group, *role)
+	require.NoError(t, err)
+	_, _, err = client.Groups.AddMembers(context.Background(), *group, userID)
+	require.NoError(t, err)
+
+	alice := newIAMClient(t, fake)
+	require.NoError(t, alice.Login("alice", "Passw0rd!"))
+	introspect, _, err := alice.Introspect()
+	require.NoError(t, err)
+	require.Len(t, introspect.Organizations.OrganizationList, 1)
+	org := introspect.Organizations.OrganizationList[0]
+	assert.Equal(t, orgID, org.OrganizationID)
+	assert.Equal(t, []string{"USER.READ"}, org.Permissions)
+	assert.Equal(t, []string{"readers"}, org.Groups)
+}
+
+func TestOrganizationNotFound(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client := newIAMClient(t, fake)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	_, resp, err := client.Organizations.GetOrganizationByID("missing")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+}
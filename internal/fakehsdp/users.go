@@ -0,0 +1,465 @@
+package fakehsdp
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type name struct {
+	Text   string `json:"text,omitempty"`
+	Family string `json:"family"`
+	Given  string `json:"given"`
+}
+
+type user struct {
+	ID                   string
+	LoginID              string
+	Password             string
+	EmailAddress         string
+	MobilePhone          string
+	Name                 name
+	ManagingOrganization string
+	PreferredLanguage    string
+	PreferredChannel     string
+	Disabled             bool
+	MFA                  bool
+	Locked               bool
+	MustChangePassword   bool
+	Activations          int
+	MFAResets            int
+}
+
+// document renders the user as returned by the v3 User search API
+func (u *user) document() map[string]interface{} {
+	accountStatus := map[string]interface{}{
+		"mfaStatus":          map[bool]string{true: "ACTIVE", false: "NOTREQUIRED"}[u.MFA],
+		"emailVerified":      true,
+		"disabled":           u.Disabled,
+		"mustChangePassword": u.MustChangePassword,
+	}
+	if u.Locked {
+		now := time.Now().UTC()
+		accountStatus["accountLockedOn"] = now
+		accountStatus["accountLockedUntil"] = now.Add(time.Hour)
+	}
+	return map[string]interface{}{
+		"id":                            u.ID,
+		"loginId":                       u.LoginID,
+		"emailAddress":                  u.EmailAddress,
+		"phoneNumber":                   u.MobilePhone,
+		"name":                          u.Name,
+		"managingOrganization":          u.ManagingOrganization,
+		"preferredLanguage":             u.PreferredLanguage,
+		"preferredCommunicationChannel": u.PreferredChannel,
+		"passwordStatus":                map[string]interface{}{},
+		"accountStatus":                 accountStatus,
+	}
+}
+
+// profile renders the user as returned by the legacy security/users API
+func (u *user) profile() map[string]interface{} {
+	return map[string]interface{}{
+		"givenName":                     u.Name.Given,
+		"familyName":                    u.Name.Family,
+		"preferredLanguage":             u.PreferredLanguage,
+		"preferredCommunicationChannel": u.PreferredChannel,
+		"disabled":                      u.Disabled,
+		"contact": map[string]string{
+			"emailAddress": u.EmailAddress,
+			"mobilePhone":  u.MobilePhone,
+		},
+	}
+}
+
+// UserState describes account flags of a user which are not part of its
+// Terraform representation
+type UserState struct {
+	Disabled           bool
+	MFA                bool
+	Locked             bool
+	MustChangePassword bool
+	Activations        int
+	MFAResets          int
+}
+
+// AddUser seeds an activated user in orgID and returns its ID
+func (s *Server) AddUser(loginID, password, orgID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := &user{
+		ID:                   uuid.NewString(),
+		LoginID:              loginID,
+		Password:             password,
+		EmailAddress:         loginID + "@example.com",
+		Name:                 name{Given: loginID, Family: "Fake"},
+		ManagingOrganization: orgID,
+	}
+	s.users[u.ID] = u
+	return u.ID
+}
+
+// User returns the account state of a user
+func (s *Server) User(id string) (UserState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return UserState{}, false
+	}
+	return UserState{
+		Disabled:           u.Disabled,
+		MFA:                u.MFA,
+		Locked:             u.Locked,
+		MustChangePassword: u.MustChangePassword,
+		Activations:        u.Activations,
+		MFAResets:          u.MFAResets,
+	}, true
+}
+
+// UserPassword returns the current password of a user
+func (s *Server) UserPassword(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[id]; ok {
+		return u.Password
+	}
+	return ""
+}
+
+// SetUserMFA enrolls or unenrolls a user in MFA without going through the API
+func (s *Server) SetUserMFA(id string, active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[id]; ok {
+		u.MFA = active
+	}
+}
+
+// LockUser marks a user as locked out, as repeated failed logins would
+func (s *Server) LockUser(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[id]; ok {
+		u.Locked = true
+	}
+}
+
+func (s *Server) userByLogin(loginID string) *user {
+	for _, u := range s.users {
+		if strings.EqualFold(u.LoginID, loginID) || strings.EqualFold(u.EmailAddress, loginID) {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) sortedUsers() []*user {
+	users := make([]*user, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].LoginID < users[j].LoginID })
+	return users
+}
+
+func (s *Server) registerUsers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /authorize/identity/User", s.authorized(s.createUser))
+	mux.HandleFunc("GET /authorize/identity/User", s.authorized(s.searchUsers))
+	mux.HandleFunc("DELETE /authorize/identity/User/{id}", s.authorized(s.deleteUser))
+	mux.HandleFunc("POST /authorize/identity/User/{action}", s.authorized(s.userAction))
+	mux.HandleFunc("POST /authorize/identity/User/{id}/{action}", s.authorized(s.userIDAction))
+	mux.HandleFunc("GET /security/users", s.authorized(s.legacySearchUsers))
+	mux.HandleFunc("GET /security/users/{id}", s.authorized(s.legacyGetUser))
+	mux.HandleFunc("PUT /security/users/{id}", s.authorized(s.legacyUpdateUser))
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var person struct {
+		LoginID                       string `json:"loginId"`
+		Name                          name   `json:"name"`
+		ManagingOrganization          string `json:"managingOrganization"`
+		PreferredLanguage             string `json:"preferredLanguage"`
+		PreferredCommunicationChannel string `json:"preferredCommunicationChannel"`
+		Password                      string `json:"password"`
+		Telecom                       []struct {
+			System string `json:"system"`
+			Value  string `json:"value"`
+		} `json:"telecom"`
+	}
+	if err := readJSON(r, &person); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[person.ManagingOrganization]; !ok {
+		writeError(w, http.StatusBadRequest, "managing organization not found")
+		return
+	}
+	if s.userByLogin(person.LoginID) != nil {
+		writeError(w, http.StatusConflict, "user already exists")
+		return
+	}
+	u := &user{
+		ID:                   uuid.NewString(),
+		LoginID:              person.LoginID,
+		Password:             person.Password,
+		Name:                 person.Name,
+		ManagingOrganization: person.ManagingOrganization,
+		PreferredLanguage:    person.PreferredLanguage,
+		PreferredChannel:     person.PreferredCommunicationChannel,
+		Activations:          1,
+	}
+	for _, t := range person.Telecom {
+		switch t.System {
+		case "email":
+			u.EmailAddress = t.Value
+		case "mobile":
+			u.MobilePhone = t.Value
+		}
+	}
+	s.users[u.ID] = u
+	w.Header().Set("Location", "/authorize/identity/User/"+u.ID)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{})
+}
+
+func (s *Server) searchUsers(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("userId")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		u = s.userByLogin(userID)
+	}
+	if u == nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"total": 0, "entry": []interface{}{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total": 1,
+		"entry": []interface{}{u.document()},
+	})
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	if _, ok := s.users[id]; !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	delete(s.users, id)
+	for _, g := range s.groups {
+		if containsString(g.members[memberTypeUser], id) {
+			g.removeMembers(memberTypeUser, id)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userAction handles the Parameters based actions which address users by login ID
+func (s *Server) userAction(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Parameter []struct {
+			Name     string `json:"name"`
+			Resource struct {
+				LoginID     string `json:"loginId"`
+				OldPassword string `json:"oldPassword"`
+				NewPassword string `json:"newPassword"`
+			} `json:"resource"`
+		} `json:"parameter"`
+	}
+	if err := readJSON(r, &body); err != nil || len(body.Parameter) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid parameters")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	params := body.Parameter[0].Resource
+	u := s.userByLogin(params.LoginID)
+	if u == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	switch action := r.PathValue("action"); action {
+	case "$resend-activation":
+		u.Activations++
+	case "$change-password":
+		if u.Password != params.OldPassword {
+			writeError(w, http.StatusUnprocessableEntity, "old password does not match")
+			return
+		}
+		u.Password = params.NewPassword
+		u.MustChangePassword = false
+	default:
+		writeError(w, http.StatusNotFound, "unsupported action: "+action)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *Server) userIDAction(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Activate string `json:"activate"`
+		LoginID  string `json:"loginId"`
+	}
+	if err := readJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	switch action := r.PathValue("action"); action {
+	case "$mfa":
+		if u.MFA && body.Activate != "true" {
+			u.MFAResets++
+		}
+		u.MFA = body.Activate == "true"
+		w.WriteHeader(http.StatusAccepted)
+	case "$unlock":
+		u.Locked = false
+		w.WriteHeader(http.StatusNoContent)
+	case "$change-loginid":
+		if existing := s.userByLogin(body.LoginID); existing != nil && existing != u {
+			writeError(w, http.StatusConflict, "login ID already in use")
+			return
+		}
+		u.LoginID = body.LoginID
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, "unsupported action: "+action)
+	}
+}
+
+func (s *Server) legacySearchUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []*user
+	if loginID := q.Get("loginId"); loginID != "" {
+		if u := s.userByLogin(loginID); u != nil {
+			matched = append(matched, u)
+		}
+	} else {
+		var members []string
+		groupID := q.Get("groupId")
+		if g, ok := s.groups[groupID]; ok {
+			members = g.members[memberTypeUser]
+		}
+		for _, u := range s.sortedUsers() {
+			if orgID := q.Get("organizationID"); orgID != "" && u.ManagingOrganization != orgID {
+				continue
+			}
+			if groupID != "" && !containsString(members, u.ID) {
+				continue
+			}
+			matched = append(matched, u)
+		}
+	}
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	pageNumber, _ := strconv.Atoi(q.Get("pageNumber"))
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+	start := (pageNumber - 1) * pageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	users := make([]map[string]string, 0, end-start)
+	for _, u := range matched[start:end] {
+		users = append(users, map[string]string{"userUUID": u.ID})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"exchange": map[string]interface{}{
+			"users":          users,
+			"nextPageExists": end < len(matched),
+		},
+		"responseCode":    "200",
+		"responseMessage": "Success",
+	})
+}
+
+func (s *Server) legacyGetUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"exchange": map[string]interface{}{
+			"loginId": u.LoginID,
+			"profile": u.profile(),
+		},
+		"responseCode":    "200",
+		"responseMessage": "Success",
+	})
+}
+
+func (s *Server) legacyUpdateUser(w http.ResponseWriter, r *http.Request) {
+	var profile struct {
+		GivenName                     string `json:"givenName"`
+		FamilyName                    string `json:"familyName"`
+		PreferredLanguage             string `json:"preferredLanguage"`
+		PreferredCommunicationChannel string `json:"preferredCommunicationChannel"`
+		Disabled                      *bool  `json:"disabled"`
+		MustChangePassword            string `json:"mustChangePassword"`
+		Contact                       struct {
+			EmailAddress string `json:"emailAddress"`
+			MobilePhone  string `json:"mobilePhone"`
+		} `json:"contact"`
+	}
+	if err := readJSON(r, &profile); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	u.Name.Given = profile.GivenName
+	u.Name.Family = profile.FamilyName
+	u.PreferredLanguage = profile.PreferredLanguage
+	u.PreferredChannel = profile.PreferredCommunicationChannel
+	if profile.Contact.EmailAddress != "" {
+		u.EmailAddress = profile.Contact.EmailAddress
+	}
+	u.MobilePhone = profile.Contact.MobilePhone
+	if profile.Disabled != nil {
+		u.Disabled = *profile.Disabled
+	}
+	if profile.MustChangePassword == "true" {
+		u.MustChangePassword = true
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"exchange": map[string]interface{}{
+			"userUUID": u.ID,
+			"loginId":  u.LoginID,
+			"profile":  u.profile(),
+		},
+		"responseCode":    "200",
+		"responseMessage": "Success",
+	})
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// maxLabels is the number of labels an AI resource supports
+const maxLabels = 20
+
+// LabelsAllSchema returns the schema of the labels_all attribute
+func LabelsAllSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: "The labels of the resource including the provider default_tags as key=value labels.",
+	}
+}
+
+// CustomizeDiffLabelsAll plans labels_all for new resources. The resources
+// cannot be updated, so provider default_tags only apply when a resource is
+// created and labels_all keeps reporting the labels it actually carries.
+func CustomizeDiffLabelsAll(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() != "" && !d.HasChange("labels") {
+		return nil
+	}
+	var defaults map[string]string
+	if c, ok := m.(*config.Config); ok {
+		defaults = c.DefaultTags
+	}
+	if !d.NewValueKnown("labels") {
+		return d.SetNewComputed("labels_all")
+	}
+	labels, _ := d.Get("labels").([]interface{})
+	all := tools.MergeStringTags(defaults, tools.ExpandStringList(labels))
+	if len(all) > maxLabels {
+		return fmt.Errorf("maximum of %d labels are supported, including the provider default_tags", maxLabels)
+	}
+	return d.SetNew("labels_all", all)
+}
+
+// CollectLabels returns the labels to create a resource with
+func CollectLabels(d *schema.ResourceData, c *config.Config) []string {
+	labels, _ := tools.CollectList("labels", d)
+	return tools.MergeStringTags(c.DefaultTags, labels)
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomizeDiffLabelsAll(t *testing.T) {
+	r := &schema.Resource{
+		CustomizeDiff: CustomizeDiffLabelsAll,
+		Schema: map[string]*schema.Schema{
+			"labels": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"labels_all": LabelsAllSchema(),
+		},
+	}
+	c := &config.Config{DefaultTags: map[string]string{"team": "core"}}
+	raw := terraform.NewResourceConfigRaw(map[string]interface{}{"labels": []interface{}{"CNN"}})
+
+	diff, err := r.Diff(context.Background(), nil, raw, c)
+	require.NoError(t, err)
+	assert.Equal(t, "team=core", diff.Attributes["labels_all.1"].New)
+
+	// New default tags leave existing resources alone
+	state := &terraform.InstanceState{ID: "ws", Attributes: map[string]string{
+		"labels.#": "1", "labels.0": "CNN",
+		"labels_all.#": "1", "labels_all.0": "CNN",
+	}}
+	diff, err = r.Diff(context.Background(), state, raw, c)
+	require.NoError(t, err)
+	assert.Nil(t, diff)
+}
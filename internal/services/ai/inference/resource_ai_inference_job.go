@@ -23,6 +23,7 @@ func ResourceAIInferenceJob() *schema.Resource {
 		CreateContext: resourceAIInferenceJobCreate,
 		ReadContext:   resourceAIInferenceJobRead,
 		DeleteContext: resourceAIInferenceJobDelete,
+		CustomizeDiff: helpers.CustomizeDiffLabelsAll,
 
 		Schema: map[string]*schema.Schema{
 			"endpoint": {
@@ -66,6 +67,7 @@ func ResourceAIInferenceJob() *schema.Resource {
 				ForceNew: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
+			"labels_all": helpers.LabelsAllSchema(),
 			"model": {
 				Type:     schema.TypeSet,
 				MaxItems: 1,
@@ -186,7 +188,8 @@ func resourceAIInferenceJobCreate(ctx context.Context, d *schema.ResourceData, m
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
 	commandArgs, _ := tools.CollectList("command_args", d)
-	labels, _ := tools.CollectList("labels", d)
+	labels := helpers.CollectLabels(d, c)
+	_ = d.Set("labels_all", labels)
 	computeTarget, _ := helpers.CollectComputeTarget(d)
 	computeModel, _ := helpers.CollectComputeModel(d)
 	inputs, _ := collectInputs(d)
@@ -24,6 +24,7 @@ func ResourceAIInferenceModel() *schema.Resource {
 		CreateContext: resourceAIInferenceModelCreate,
 		ReadContext:   resourceAIInferenceModelRead,
 		DeleteContext: resourceAIInferenceModelDelete,
+		CustomizeDiff: helpers.CustomizeDiffLabelsAll,
 
 		Schema: map[string]*schema.Schema{
 			"endpoint": {
@@ -71,6 +72,7 @@ func ResourceAIInferenceModel() *schema.Resource {
 				ForceNew: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
+			"labels_all": helpers.LabelsAllSchema(),
 			"compute_environment": {
 				Type:     schema.TypeSet,
 				MaxItems: 1,
@@ -152,7 +154,8 @@ func resourceAIInferenceModelCreate(ctx context.Context, d *schema.ResourceData,
 	version := d.Get("version").(string)
 	artifactPath := d.Get("artifact_path").(string)
 	entryCommands, _ := tools.CollectList("entry_commands", d)
-	labels, _ := tools.CollectList("labels", d)
+	labels := helpers.CollectLabels(d, c)
+	_ = d.Set("labels_all", labels)
 	computeEnvironment, _ := helpers.CollectComputeEnvironment(d)
 	sourceCode, _ := helpers.CollectSourceCode(d)
 	additionalConfiguration := d.Get("additional_configuration").(string)
@@ -24,6 +24,7 @@ func ResourceAIWorkspace() *schema.Resource {
 		CreateContext: resourceAIWorkspaceCreate,
 		ReadContext:   resourceAIWorkspaceRead,
 		DeleteContext: resourceAIWorkspaceDelete,
+		CustomizeDiff: helpers.CustomizeDiffLabelsAll,
 
 		Schema: map[string]*schema.Schema{
 			"endpoint": {
@@ -48,6 +49,7 @@ func ResourceAIWorkspace() *schema.Resource {
 				ForceNew: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
+			"labels_all": helpers.LabelsAllSchema(),
 			"compute_target": {
 				Type:     schema.TypeSet,
 				MaxItems: 1,
@@ -138,7 +140,8 @@ func resourceAIWorkspaceCreate(ctx context.Context, d *schema.ResourceData, m in
 
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
-	labels, _ := tools.CollectList("labels", d)
+	labels := helpers.CollectLabels(d, c)
+	_ = d.Set("labels_all", labels)
 	computeTarget, _ := helpers.CollectComputeTarget(d)
 	sourceCode, _ := helpers.CollectSourceCode(d)
 	additionalConfiguration := d.Get("additional_conifguration").(string)
@@ -0,0 +1,38 @@
+package ch
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/philips-software/go-hsdp-api/cartel"
+)
+
+// fleetStateRefreshFunc tracks the deployment state of many instances. The
+// fleet is succeeded once every instance is.
+func fleetStateRefreshFunc(client *cartel.Client, names []string, failStates []string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		states := make(map[string]string, len(names))
+		pending := 0
+		for _, name := range names {
+			state, _, err := client.GetDeploymentState(name)
+			if err != nil {
+				log.Printf("Error on FleetStateRefresh: %s", err)
+				return nil, "", fmt.Errorf("cartel deployment status of '%s': %w", name, err)
+			}
+			states[name] = state
+			for _, failState := range failStates {
+				if state == failState {
+					return states, state, fmt.Errorf("instance '%s' failed to reach target state, reason: %s", name, state)
+				}
+			}
+			if state != "succeeded" {
+				pending++
+			}
+		}
+		if pending > 0 {
+			return states, "provisioning", nil
+		}
+		return states, "succeeded", nil
+	}
+}
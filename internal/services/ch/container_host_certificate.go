@@ -0,0 +1,173 @@
+package ch
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshCertificateField = "ssh_certificate"
+
+	defaultProvisioningCertificateTTL = "15m"
+
+	// signCommandTimeout bounds how long a sign_command may run
+	signCommandTimeout = time.Minute
+
+	// certificateClockSkew is how far the validity of a signed certificate
+	// may stretch beyond the requested ttl
+	certificateClockSkew = 5 * time.Minute
+)
+
+// sshCertificateFieldSchema configures a CA which signs short-lived OpenSSH
+// user certificates for provisioning
+func sshCertificateFieldSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{"private_key"},
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"sign_command": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
+				"principal": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"ttl": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      defaultProvisioningCertificateTTL,
+					ValidateFunc: validateDuration,
+				},
+			},
+		},
+	}
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	d, err := time.ParseDuration(v.(string))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as 15m: %w", k, err))
+	} else if d <= 0 {
+		errors = append(errors, fmt.Errorf("%q must be positive", k))
+	}
+	return
+}
+
+// signFunc certifies publicKey, in authorized_keys format, for principal and
+// returns the OpenSSH certificate in authorized_keys format
+type signFunc func(publicKey []byte, principal string, ttl time.Duration) ([]byte, error)
+
+// commandSigner returns a signFunc which runs command through the shell with
+// the public key on standard input. The principal and ttl are passed in the
+// HSDP_SSH_PRINCIPAL and HSDP_SSH_TTL environment variables.
+func commandSigner(command string) signFunc {
+	return func(publicKey []byte, principal string, ttl time.Duration) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), signCommandTimeout)
+		defer cancel()
+
+		cmd := config.ShellCommand(ctx, command)
+		cmd.Env = append(os.Environ(),
+			"HSDP_SSH_PRINCIPAL="+principal,
+			fmt.Sprintf("HSDP_SSH_TTL=%ds", int(ttl.Seconds())),
+		)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdin = bytes.NewReader(publicKey)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("sign_command failed: %w: %s", err, msg)
+			}
+			return nil, fmt.Errorf("sign_command failed: %w", err)
+		}
+		return stdout.Bytes(), nil
+	}
+}
+
+// issueProvisioningCertificate generates a key pair which is only kept in
+// memory and has sign certify it for principal. The returned signer presents
+// the certificate, so hosts which trust the CA through TrustedUserCAKeys
+// accept it until the certificate expires.
+func issueProvisioningCertificate(sign signFunc, principal string, ttl time.Duration) (ssh.Signer, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating provisioning key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return nil, err
+	}
+	output, err := sign(ssh.MarshalAuthorizedKey(signer.PublicKey()), principal, ttl)
+	if err != nil {
+		return nil, err
+	}
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey(output)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signed certificate: %w", err)
+	}
+	cert, ok := parsed.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("sign_command returned a %s public key instead of an OpenSSH certificate", parsed.Type())
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("sign_command returned a host certificate instead of a user certificate")
+	}
+	if !bytes.Equal(cert.Key.Marshal(), signer.PublicKey().Marshal()) {
+		return nil, fmt.Errorf("sign_command returned a certificate for a different key")
+	}
+	if !containsPrincipal(cert.ValidPrincipals, principal) {
+		return nil, fmt.Errorf("certificate is not valid for principal %q, it has %v", principal, cert.ValidPrincipals)
+	}
+	now := time.Now()
+	if cert.ValidBefore <= uint64(now.Unix()) {
+		return nil, fmt.Errorf("certificate has already expired")
+	}
+	if cert.ValidBefore == ssh.CertTimeInfinity || cert.ValidBefore > uint64(now.Add(ttl+certificateClockSkew).Unix()) {
+		return nil, fmt.Errorf("certificate is valid for longer than ttl %s", ttl)
+	}
+	return ssh.NewCertSigner(cert, signer)
+}
+
+func containsPrincipal(principals []string, principal string) bool {
+	for _, p := range principals {
+		if p == principal {
+			return true
+		}
+	}
+	return false
+}
+
+// provisioningCertificate returns a signer presenting a freshly signed user
+// certificate when the ssh_certificate block is set, or nil otherwise
+func provisioningCertificate(d *schema.ResourceData) (ssh.Signer, error) {
+	settings, ok := d.Get(sshCertificateField).([]interface{})
+	if !ok || len(settings) == 0 || settings[0] == nil {
+		return nil, nil
+	}
+	block := settings[0].(map[string]interface{})
+	principal, _ := block["principal"].(string)
+	if principal == "" {
+		principal = d.Get("user").(string)
+	}
+	ttl, err := time.ParseDuration(block["ttl"].(string))
+	if err != nil {
+		return nil, fmt.Errorf("ssh_certificate ttl: %w", err)
+	}
+	return issueProvisioningCertificate(commandSigner(block["sign_command"].(string)), principal, ttl)
+}
@@ -0,0 +1,132 @@
+package ch
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testCA signs user certificates the way a sign_command would
+type testCA struct {
+	signer ssh.Signer
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	return &testCA{signer: signer}
+}
+
+// sign returns a signFunc which lets modify adjust the certificate before it is signed
+func (ca *testCA) sign(modify func(cert *ssh.Certificate)) signFunc {
+	return func(publicKey []byte, principal string, ttl time.Duration) ([]byte, error) {
+		key, _, _, _, err := ssh.ParseAuthorizedKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		cert := &ssh.Certificate{
+			Key:             key,
+			CertType:        ssh.UserCert,
+			KeyId:           "terraform",
+			ValidPrincipals: []string{principal, "bastion"},
+			ValidAfter:      uint64(now.Add(-time.Minute).Unix()),
+			ValidBefore:     uint64(now.Add(ttl).Unix()),
+		}
+		if modify != nil {
+			modify(cert)
+		}
+		if err := cert.SignCert(rand.Reader, ca.signer); err != nil {
+			return nil, err
+		}
+		return ssh.MarshalAuthorizedKey(cert), nil
+	}
+}
+
+func TestIssueProvisioningCertificate(t *testing.T) {
+	ca := newTestCA(t)
+
+	signer, err := issueProvisioningCertificate(ca.sign(nil), "core", 15*time.Minute)
+	require.NoError(t, err)
+	cert, ok := signer.PublicKey().(*ssh.Certificate)
+	require.True(t, ok)
+	assert.Contains(t, cert.ValidPrincipals, "core")
+	assert.Equal(t, ca.signer.PublicKey().Marshal(), cert.SignatureKey.Marshal())
+
+	// Every run certifies a fresh key
+	other, err := issueProvisioningCertificate(ca.sign(nil), "core", 15*time.Minute)
+	require.NoError(t, err)
+	assert.NotEqual(t, cert.Key.Marshal(), other.PublicKey().(*ssh.Certificate).Key.Marshal())
+
+	_, err = issueProvisioningCertificate(ca.sign(func(cert *ssh.Certificate) {
+		cert.ValidPrincipals = []string{"root"}
+	}), "core", 15*time.Minute)
+	assert.ErrorContains(t, err, `not valid for principal "core"`)
+
+	_, err = issueProvisioningCertificate(ca.sign(func(cert *ssh.Certificate) {
+		cert.ValidBefore = ssh.CertTimeInfinity
+	}), "core", 15*time.Minute)
+	assert.ErrorContains(t, err, "valid for longer than ttl")
+
+	_, err = issueProvisioningCertificate(ca.sign(func(cert *ssh.Certificate) {
+		cert.CertType = ssh.HostCert
+	}), "core", 15*time.Minute)
+	assert.ErrorContains(t, err, "host certificate")
+
+	_, err = issueProvisioningCertificate(ca.sign(func(cert *ssh.Certificate) {
+		cert.Key = ca.signer.PublicKey()
+	}), "core", 15*time.Minute)
+	assert.ErrorContains(t, err, "different key")
+
+	// A sign_command which echoes the key back did not certify anything
+	_, err = issueProvisioningCertificate(func(publicKey []byte, _ string, _ time.Duration) ([]byte, error) {
+		return publicKey, nil
+	}, "core", 15*time.Minute)
+	assert.ErrorContains(t, err, "instead of an OpenSSH certificate")
+}
+
+func TestCommandSigner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	output, err := commandSigner(`printf '%s %s\n' "$HSDP_SSH_PRINCIPAL" "$HSDP_SSH_TTL"; cat`)([]byte("ssh-ed25519 AAAA\n"), "core", 15*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "core 900s\nssh-ed25519 AAAA\n", string(output))
+
+	_, err = commandSigner("echo permission denied >&2; exit 2")(nil, "core", time.Minute)
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestSharedBastionCertificate(t *testing.T) {
+	server, _ := newTestSSHServer(t)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ca := newTestCA(t)
+	certificate, err := issueProvisioningCertificate(ca.sign(nil), "core", time.Minute)
+	require.NoError(t, err)
+
+	// Hosts which do not trust the CA reject the certificate
+	_, err = dialBastion(server.config(""), hostKeyPolicy{}, certificate)
+	require.Error(t, err)
+
+	server.mu.Lock()
+	server.userCA = ca.signer.PublicKey()
+	server.mu.Unlock()
+	bastion, host, err := connectHost(server.config(""), hostKeyPolicy{}, certificate)
+	require.NoError(t, err)
+	defer func() {
+		_ = bastion.Close()
+	}()
+	stdout, _, err := runCommands([]string{"uptime"}, host, &config.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "ran: uptime\n", stdout)
+}
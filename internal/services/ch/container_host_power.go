@@ -0,0 +1,109 @@
+package ch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/philips-software/go-hsdp-api/cartel"
+)
+
+const (
+	instanceStateRunning = "running"
+	instanceStateStopped = "stopped"
+)
+
+// errHostStopped is returned when rebooting an instance which is stopped
+var errHostStopped = errors.New("container host is stopped")
+
+// powerPollInterval is how often the run state is polled during power transitions
+var powerPollInterval = 5 * time.Second
+
+// instanceRunStateRefreshFunc tracks the EC2 run state of an instance, unlike
+// instanceStateRefreshFunc which tracks the Cartel deployment state
+func instanceRunStateRefreshFunc(client *cartel.Client, nameTag string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		details, _, err := client.GetDetails(nameTag)
+		if err != nil {
+			return nil, "", err
+		}
+		return details, details.State, nil
+	}
+}
+
+func waitForInstanceState(ctx context.Context, client *cartel.Client, nameTag, target string, timeout time.Duration) error {
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{"pending", "stopping", "stopped", "running", "shutting-down"},
+		Target:     []string{target},
+		Refresh:    instanceRunStateRefreshFunc(client, nameTag),
+		Timeout:    timeout,
+		Delay:      powerPollInterval,
+		MinTimeout: powerPollInterval,
+	}
+	_, err := stateConf.WaitForStateContext(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for '%s' to become %s: %w", nameTag, target, err)
+	}
+	return nil
+}
+
+// currentInstanceState returns the EC2 run state of an instance
+func currentInstanceState(client *cartel.Client, nameTag string) (string, error) {
+	_, state, err := instanceRunStateRefreshFunc(client, nameTag)()
+	return state, err
+}
+
+func stopContainerHost(ctx context.Context, client *cartel.Client, nameTag string, timeout time.Duration) error {
+	state, err := currentInstanceState(client, nameTag)
+	if err != nil {
+		return err
+	}
+	if state == instanceStateStopped {
+		return nil
+	}
+	if _, _, err := client.Stop(nameTag); err != nil {
+		return fmt.Errorf("stopping '%s': %w", nameTag, err)
+	}
+	return waitForInstanceState(ctx, client, nameTag, instanceStateStopped, timeout)
+}
+
+func startContainerHost(ctx context.Context, client *cartel.Client, nameTag string, timeout time.Duration) error {
+	state, err := currentInstanceState(client, nameTag)
+	if err != nil {
+		return err
+	}
+	if state == instanceStateRunning {
+		return nil
+	}
+	if _, _, err := client.Start(nameTag); err != nil {
+		return fmt.Errorf("starting '%s': %w", nameTag, err)
+	}
+	return waitForInstanceState(ctx, client, nameTag, instanceStateRunning, timeout)
+}
+
+// rebootContainerHost power cycles a running instance. Cartel has no reboot
+// call so this is a stop followed by a start. Stopped instances are left
+// stopped and errHostStopped is returned.
+func rebootContainerHost(ctx context.Context, client *cartel.Client, nameTag string, timeout time.Duration) error {
+	state, err := currentInstanceState(client, nameTag)
+	if err != nil {
+		return err
+	}
+	if state == instanceStateStopped {
+		return fmt.Errorf("rebooting '%s': %w", nameTag, errHostStopped)
+	}
+	if err := stopContainerHost(ctx, client, nameTag, timeout); err != nil {
+		return err
+	}
+	return startContainerHost(ctx, client, nameTag, timeout)
+}
+
+// setContainerHostState brings an instance to the desired run state
+func setContainerHostState(ctx context.Context, client *cartel.Client, nameTag, desired string, timeout time.Duration) error {
+	if desired == instanceStateStopped {
+		return stopContainerHost(ctx, client, nameTag, timeout)
+	}
+	return startContainerHost(ctx, client, nameTag, timeout)
+}
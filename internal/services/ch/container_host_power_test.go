@@ -0,0 +1,97 @@
+package ch
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// The fake Cartel changes state immediately
+	powerPollInterval = 10 * time.Millisecond
+	fleetPollInterval = 10 * time.Millisecond
+}
+
+func fakeCartelConfig(fake *fakehsdp.Server) *config.Config {
+	c := &config.Config{
+		CartelHost:   strings.TrimPrefix(fake.URL, "http://"),
+		CartelToken:  fakehsdp.CartelToken,
+		CartelSecret: fakehsdp.CartelSecret,
+		CartelNoTLS:  true,
+	}
+	c.SetupCartelClient()
+	return c
+}
+
+func TestContainerHostPower(t *testing.T) {
+	ctx := context.Background()
+	fake := fakehsdp.New(t)
+	fake.AddContainerHost("host", nil)
+	c := fakeCartelConfig(fake)
+	client, err := c.CartelClient()
+	require.NoError(t, err)
+
+	require.NoError(t, setContainerHostState(ctx, client, "host", instanceStateStopped, time.Minute))
+	assert.Equal(t, instanceStateStopped, fake.ContainerHostState("host"))
+	// Already stopped hosts are left alone
+	require.NoError(t, stopContainerHost(ctx, client, "host", time.Minute))
+	assert.Equal(t, 1, fake.Requests(http.MethodPost, "/v3/api/suspend"))
+
+	require.NoError(t, setContainerHostState(ctx, client, "host", instanceStateRunning, time.Minute))
+	assert.Equal(t, instanceStateRunning, fake.ContainerHostState("host"))
+
+	require.NoError(t, rebootContainerHost(ctx, client, "host", time.Minute))
+	assert.Equal(t, instanceStateRunning, fake.ContainerHostState("host"))
+	assert.Equal(t, 2, fake.Requests(http.MethodPost, "/v3/api/suspend"))
+	assert.Equal(t, 2, fake.Requests(http.MethodPost, "/v3/api/start"))
+
+	// Stopped hosts are not started by a reboot
+	require.NoError(t, stopContainerHost(ctx, client, "host", time.Minute))
+	assert.ErrorIs(t, rebootContainerHost(ctx, client, "host", time.Minute), errHostStopped)
+	assert.Equal(t, instanceStateStopped, fake.ContainerHostState("host"))
+	assert.Equal(t, 2, fake.Requests(http.MethodPost, "/v3/api/start"))
+}
+
+func TestExistingGroupHosts(t *testing.T) {
+	fake := fakehsdp.New(t)
+	for _, name := range []string{"web-0", "web-2", "web-5"} {
+		fake.AddContainerHost(name, nil)
+	}
+	client, err := fakeCartelConfig(fake).CartelClient()
+	require.NoError(t, err)
+
+	// Cartel rejects the batch because of the unknown names, only those are left out
+	details, err := existingGroupHosts(client, []string{"web-0", "web-1", "web-2", "web-3", "web-4", "web-5"})
+	require.NoError(t, err)
+	assert.Len(t, details, 3)
+	for _, name := range []string{"web-0", "web-2", "web-5"} {
+		assert.Contains(t, details, name)
+	}
+
+	details, err = existingGroupHosts(client, []string{"web-1", "web-3"})
+	require.NoError(t, err)
+	assert.Empty(t, details)
+}
+
+func TestFleetStateRefresh(t *testing.T) {
+	fake := fakehsdp.New(t)
+	fake.AddContainerHost("web-0", nil)
+	fake.AddContainerHost("web-1", nil)
+	client, err := fakeCartelConfig(fake).CartelClient()
+	require.NoError(t, err)
+
+	states, state, err := fleetStateRefreshFunc(client, []string{"web-0", "web-1"}, []string{"failed"})()
+	require.NoError(t, err)
+	assert.Equal(t, "succeeded", state)
+	assert.Equal(t, map[string]string{"web-0": "succeeded", "web-1": "succeeded"}, states)
+
+	_, _, err = fleetStateRefreshFunc(client, []string{"web-0", "bogus"}, []string{"failed"})()
+	assert.ErrorContains(t, err, "bogus")
+}
@@ -0,0 +1,358 @@
+package ch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loafoe/easyssh-proxy/v2"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultCommandTimeout matches the easyssh default for commands without a timeout
+const defaultCommandTimeout = 60 * time.Second
+
+// remoteHost runs commands on and copies files to a provisioned instance.
+// *easyssh.MakeConfig connects for every call, *tunnelHost reuses a
+// connection made through a sharedBastion.
+type remoteHost interface {
+	Run(command string, timeout ...time.Duration) (string, string, bool, error)
+	WriteFile(reader io.Reader, size int64, target string) error
+}
+
+// remoteAddress returns the address of a remote host for logging
+func remoteAddress(r remoteHost) string {
+	switch h := r.(type) {
+	case *easyssh.MakeConfig:
+		return h.Server
+	case *tunnelHost:
+		return h.server
+	}
+	return "remote"
+}
+
+// hostKeyPolicy decides which host keys are accepted for the bastion and the
+// instances. A pinned key is either a public key in authorized_keys format or
+// a SHA256 fingerprint. Without pinned keys or a known_hosts file any host key
+// is accepted, like easyssh does.
+type hostKeyPolicy struct {
+	BastionHostKey string
+	HostKey        string
+	KnownHostsFile string
+}
+
+// callback returns a host key callback which verifies against pinned and the
+// known_hosts file, and stores the presented key in observed
+func (p hostKeyPolicy) callback(pinned string, observed *string) (ssh.HostKeyCallback, error) {
+	var known ssh.HostKeyCallback
+	if p.KnownHostsFile != "" {
+		cb, err := knownhosts.New(p.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading known_hosts: %w", err)
+		}
+		known = cb
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		*observed = formatHostKey(key)
+		if pinned != "" && !hostKeyMatches(pinned, key) {
+			return fmt.Errorf("host key mismatch for %s: got %s", hostname, ssh.FingerprintSHA256(key))
+		}
+		if known != nil {
+			return known(hostname, remote, key)
+		}
+		return nil
+	}, nil
+}
+
+// formatHostKey returns key in authorized_keys format
+func formatHostKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+func hostKeyMatches(pinned string, key ssh.PublicKey) bool {
+	if strings.HasPrefix(pinned, "SHA256:") {
+		return ssh.FingerprintSHA256(key) == pinned
+	}
+	pinnedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pinned))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(pinnedKey.Marshal(), key.Marshal())
+}
+
+// validateHostKey accepts SHA256 fingerprints and authorized_keys formatted keys
+func validateHostKey(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if strings.HasPrefix(value, "SHA256:") {
+		return
+	}
+	if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a SHA256 fingerprint or a public key in authorized_keys format: %w", k, err))
+	}
+	return
+}
+
+// sharedBastion is a single SSH connection to a bastion host through which
+// sessions to many instances are tunneled
+type sharedBastion struct {
+	client     *ssh.Client
+	user       string
+	auths      []ssh.AuthMethod
+	policy     hostKeyPolicy
+	targetPort string
+	agentConn  io.Closer
+
+	// observedKey is the host key the bastion presented
+	observedKey string
+}
+
+// dialBastion connects to the bastion of cfg. The returned bastion reuses the
+// credentials of cfg for the instances it connects to. A certificate signer is
+// offered before the key of cfg and any agent keys.
+func dialBastion(cfg *easyssh.MakeConfig, policy hostKeyPolicy, certificate ...ssh.Signer) (*sharedBastion, error) {
+	var auths []ssh.AuthMethod
+	for _, signer := range certificate {
+		if signer != nil {
+			auths = append(auths, ssh.PublicKeys(signer))
+		}
+	}
+	if cfg.Key != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(cfg.Key))
+		if err != nil {
+			return nil, fmt.Errorf("parsing private key: %w", err)
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	var agentConn net.Conn
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			agentConn = conn
+			auths = append(auths, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	closeAgent := func() {
+		if agentConn != nil {
+			_ = agentConn.Close()
+		}
+	}
+	b := &sharedBastion{
+		user:       cfg.User,
+		auths:      auths,
+		policy:     policy,
+		targetPort: cfg.Port,
+		agentConn:  agentConn,
+	}
+	hostKeyCallback, err := policy.callback(policy.BastionHostKey, &b.observedKey)
+	if err != nil {
+		closeAgent()
+		return nil, err
+	}
+	bastionAddr := net.JoinHostPort(cfg.Bastion.Server, cfg.Bastion.Port)
+	conn, err := dialThroughProxy(cfg, bastionAddr)
+	if err != nil {
+		closeAgent()
+		return nil, err
+	}
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, bastionAddr, &ssh.ClientConfig{
+		User:            cfg.Bastion.User,
+		Auth:            auths,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         defaultCommandTimeout,
+	})
+	if err != nil {
+		_ = conn.Close()
+		closeAgent()
+		return nil, fmt.Errorf("connecting to bastion %s: %w", bastionAddr, err)
+	}
+	b.client = ssh.NewClient(ncc, chans, reqs)
+	return b, nil
+}
+
+// dialThroughProxy connects to addr, using an HTTP CONNECT proxy when cfg has one
+func dialThroughProxy(cfg *easyssh.MakeConfig, addr string) (net.Conn, error) {
+	if cfg.Proxy != nil {
+		req, _ := http.NewRequest(http.MethodConnect, "https://"+addr, nil)
+		proxyURL, err := cfg.Proxy(req)
+		if err == nil && proxyURL != nil {
+			conn, err := net.DialTimeout("tcp", proxyURL.Host, defaultCommandTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("connecting to proxy %s: %w", proxyURL.Host, err)
+			}
+			header := fmt.Sprintf("CONNECT %[1]s HTTP/1.1\r\nHost: %[1]s\r\n", addr)
+			if proxyURL.User != nil {
+				password, _ := proxyURL.User.Password()
+				auth := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+				header += "Proxy-Authorization: Basic " + auth + "\r\n"
+			}
+			if _, err := io.WriteString(conn, header+"\r\n"); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, req)
+			if err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				_ = conn.Close()
+				return nil, fmt.Errorf("proxy CONNECT %s: %s", addr, resp.Status)
+			}
+			return &bufferedConn{Conn: conn, reader: reader}, nil
+		}
+	}
+	return net.DialTimeout("tcp", addr, defaultCommandTimeout)
+}
+
+// bufferedConn keeps data the proxy handshake already read from the connection
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+// host connects to the SSH port of server through the bastion
+func (b *sharedBastion) host(server string) (*tunnelHost, error) {
+	h := &tunnelHost{server: server}
+	hostKeyCallback, err := b.policy.callback(b.policy.HostKey, &h.observedKey)
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(server, b.targetPort)
+	conn, err := b.client.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("tunneling to %s: %w", addr, err)
+	}
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            b.user,
+		Auth:            b.auths,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         defaultCommandTimeout,
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	h.client = ssh.NewClient(ncc, chans, reqs)
+	return h, nil
+}
+
+// connectHost connects to cfg.Server through the bastion of cfg. Closing the
+// bastion also closes the connection to the host.
+func connectHost(cfg *easyssh.MakeConfig, policy hostKeyPolicy, certificate ...ssh.Signer) (*sharedBastion, *tunnelHost, error) {
+	bastion, err := dialBastion(cfg, policy, certificate...)
+	if err != nil {
+		return nil, nil, err
+	}
+	host, err := bastion.host(cfg.Server)
+	if err != nil {
+		_ = bastion.Close()
+		return bastion, nil, err
+	}
+	return bastion, host, nil
+}
+
+func (b *sharedBastion) Close() error {
+	if b.agentConn != nil {
+		_ = b.agentConn.Close()
+	}
+	return b.client.Close()
+}
+
+// tunnelHost is an SSH connection to an instance, tunneled through a sharedBastion
+type tunnelHost struct {
+	server string
+	client *ssh.Client
+
+	// observedKey is the host key the instance presented
+	observedKey string
+}
+
+// Run runs command and returns its output. Like easyssh, done is false when
+// the command did not finish within timeout.
+func (h *tunnelHost) Run(command string, timeout ...time.Duration) (string, string, bool, error) {
+	session, err := h.client.NewSession()
+	if err != nil {
+		return "", "", false, err
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Start(command); err != nil {
+		return "", "", false, err
+	}
+	executeTimeout := defaultCommandTimeout
+	if len(timeout) > 0 {
+		executeTimeout = timeout[0]
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- session.Wait()
+	}()
+	select {
+	case err := <-result:
+		return stdout.String(), stderr.String(), true, err
+	case <-time.After(executeTimeout):
+		_ = session.Close()
+		<-result
+		return stdout.String(), stderr.String() + "Run Command Timeout!\n", false, nil
+	}
+}
+
+// WriteFile writes size bytes from reader to target using the scp protocol
+func (h *tunnelHost) WriteFile(reader io.Reader, size int64, target string) error {
+	session, err := h.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+	w, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	copyErr := make(chan error, 1)
+	go func() {
+		defer func() {
+			_ = w.Close()
+		}()
+		if _, err := fmt.Fprintln(w, "C0644", size, filepath.Base(target)); err != nil {
+			copyErr <- err
+			return
+		}
+		if size > 0 {
+			if _, err := io.Copy(w, reader); err != nil {
+				copyErr <- err
+				return
+			}
+		}
+		_, err := fmt.Fprint(w, "\x00")
+		copyErr <- err
+	}()
+	if err := session.Run(fmt.Sprintf("scp -tr %s", target)); err != nil {
+		return err
+	}
+	return <-copyErr
+}
+
+func (h *tunnelHost) Close() error {
+	return h.client.Close()
+}
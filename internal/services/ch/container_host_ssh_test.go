@@ -0,0 +1,304 @@
+package ch
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/loafoe/easyssh-proxy/v2"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is a minimal SSH server which acts both as bastion, by
+// forwarding direct-tcpip channels, and as instance, by echoing commands
+// and accepting scp uploads
+type testSSHServer struct {
+	listener    net.Listener
+	hostKey     ssh.PublicKey
+	mu          sync.Mutex
+	userCA      ssh.PublicKey
+	connections int
+	tunnels     int
+	commands    []string
+	files       map[string]string
+}
+
+func newTestSSHServer(t *testing.T) (*testSSHServer, string) {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	clientPublic, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authorized, err := ssh.NewPublicKey(clientPublic)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	require.NoError(t, err)
+
+	s := &testSSHServer{hostKey: hostSigner.PublicKey(), files: map[string]string{}}
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if _, ok := key.(*ssh.Certificate); ok {
+				checker := &ssh.CertChecker{IsUserAuthority: func(auth ssh.PublicKey) bool {
+					s.mu.Lock()
+					defer s.mu.Unlock()
+					return s.userCA != nil && string(auth.Marshal()) == string(s.userCA.Marshal())
+				}}
+				return checker.Authenticate(conn, key)
+			}
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, fmt.Errorf("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s.listener = listener
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			s.mu.Unlock()
+			go s.serve(conn, serverConfig)
+		}
+	}()
+	return s, string(pem.EncodeToMemory(block))
+}
+
+func (s *testSSHServer) port() string {
+	return strconv.Itoa(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *testSSHServer) serve(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "direct-tcpip":
+			var target struct {
+				Host     string
+				Port     uint32
+				OrigHost string
+				OrigPort uint32
+			}
+			_ = ssh.Unmarshal(newChannel.ExtraData(), &target)
+			upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+			if err != nil {
+				_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, requests, _ := newChannel.Accept()
+			go ssh.DiscardRequests(requests)
+			s.mu.Lock()
+			s.tunnels++
+			s.mu.Unlock()
+			go func() {
+				_, _ = io.Copy(channel, upstream)
+				_ = channel.Close()
+			}()
+			go func() {
+				_, _ = io.Copy(upstream, channel)
+				_ = upstream.Close()
+			}()
+		case "session":
+			channel, requests, _ := newChannel.Accept()
+			go s.session(channel, requests)
+		default:
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+		}
+	}
+}
+
+func (s *testSSHServer) session(channel ssh.Channel, requests <-chan *ssh.Request) {
+	for req := range requests {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		var exec struct{ Command string }
+		_ = ssh.Unmarshal(req.Payload, &exec)
+		_ = req.Reply(true, nil)
+		status := 0
+		if target, ok := strings.CutPrefix(exec.Command, "scp -tr "); ok {
+			reader := bufio.NewReader(channel)
+			header, _ := reader.ReadString('\n')
+			fields := strings.Fields(header)
+			size, _ := strconv.Atoi(fields[1])
+			content := make([]byte, size)
+			_, _ = io.ReadFull(reader, content)
+			s.mu.Lock()
+			s.files[target] = string(content)
+			s.mu.Unlock()
+		} else {
+			s.mu.Lock()
+			s.commands = append(s.commands, exec.Command)
+			s.mu.Unlock()
+			if exec.Command == "false" {
+				status = 1
+			}
+			_, _ = fmt.Fprintf(channel, "ran: %s\n", exec.Command)
+		}
+		_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+		_ = channel.Close()
+		return
+	}
+}
+
+func (s *testSSHServer) config(privateKey string) *easyssh.MakeConfig {
+	return &easyssh.MakeConfig{
+		User:   "core",
+		Key:    privateKey,
+		Server: "127.0.0.1",
+		Port:   s.port(),
+		Bastion: easyssh.DefaultConfig{
+			User:   "bastion",
+			Key:    privateKey,
+			Server: "127.0.0.1",
+			Port:   s.port(),
+		},
+	}
+}
+
+func TestHostKeyPolicy(t *testing.T) {
+	server, privateKey := newTestSSHServer(t)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	authorizedKey := formatHostKey(server.hostKey)
+	fingerprint := ssh.FingerprintSHA256(server.hostKey)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+	otherFingerprint := ssh.FingerprintSHA256(otherSigner.PublicKey())
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(net.JoinHostPort("127.0.0.1", server.port()))}, server.hostKey)
+	require.NoError(t, os.WriteFile(knownHosts, []byte(line+"\n"), 0600))
+	emptyKnownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(emptyKnownHosts, nil, 0600))
+
+	for _, tc := range []struct {
+		name   string
+		policy hostKeyPolicy
+		err    string
+	}{
+		{name: "unpinned", policy: hostKeyPolicy{}},
+		{name: "pinned keys", policy: hostKeyPolicy{BastionHostKey: authorizedKey, HostKey: authorizedKey}},
+		{name: "pinned fingerprints", policy: hostKeyPolicy{BastionHostKey: fingerprint, HostKey: fingerprint}},
+		{name: "known_hosts", policy: hostKeyPolicy{KnownHostsFile: knownHosts}},
+		{name: "bastion mismatch", policy: hostKeyPolicy{BastionHostKey: otherFingerprint}, err: "host key mismatch"},
+		{name: "host mismatch", policy: hostKeyPolicy{HostKey: formatHostKey(otherSigner.PublicKey())}, err: "host key mismatch"},
+		{name: "unknown host", policy: hostKeyPolicy{KnownHostsFile: emptyKnownHosts}, err: "key is unknown"},
+		{name: "missing known_hosts", policy: hostKeyPolicy{KnownHostsFile: filepath.Join(t.TempDir(), "missing")}, err: "reading known_hosts"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bastion, host, err := connectHost(server.config(privateKey), tc.policy)
+			if bastion != nil {
+				defer func() {
+					_ = bastion.Close()
+				}()
+			}
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, authorizedKey, bastion.observedKey)
+			assert.Equal(t, authorizedKey, host.observedKey)
+		})
+	}
+}
+
+func TestValidateHostKey(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	for _, valid := range []string{
+		formatHostKey(signer.PublicKey()),
+		ssh.FingerprintSHA256(signer.PublicKey()),
+	} {
+		_, errs := validateHostKey(valid, "host_key")
+		assert.Empty(t, errs, valid)
+	}
+	_, errs := validateHostKey("not-a-key", "host_key")
+	assert.Len(t, errs, 1)
+}
+
+func TestContainerHostKeyPolicy(t *testing.T) {
+	// Hosts and groups share the host key settings
+	raw := map[string]interface{}{
+		"bastion_host_key": "SHA256:bastion",
+		"host_key":         "SHA256:host",
+		"known_hosts_file": "/tmp/known_hosts",
+	}
+	want := hostKeyPolicy{BastionHostKey: "SHA256:bastion", HostKey: "SHA256:host", KnownHostsFile: "/tmp/known_hosts"}
+	for _, r := range []*schema.Resource{ResourceContainerHost(), ResourceContainerHostGroup()} {
+		d := schema.TestResourceDataRaw(t, r.Schema, raw)
+		assert.Equal(t, want, containerHostKeyPolicy(d))
+	}
+}
+
+func TestSharedBastion(t *testing.T) {
+	server, privateKey := newTestSSHServer(t)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	bastion, err := dialBastion(server.config(privateKey), hostKeyPolicy{})
+	require.NoError(t, err)
+	defer func() {
+		_ = bastion.Close()
+	}()
+
+	c := &config.Config{}
+	files := []provisionFile{{Content: "hello", Destination: "/tmp/hello.txt", Permissions: "0600"}}
+	for i := 0; i < 3; i++ {
+		host, err := bastion.host("127.0.0.1")
+		require.NoError(t, err)
+		require.NoError(t, copyFiles(host, c, files))
+		stdout, _, err := runCommands([]string{"uptime"}, host, c)
+		require.NoError(t, err)
+		assert.Equal(t, "ran: uptime\n", stdout)
+		_, diags, err := runCommands([]string{"false"}, host, c)
+		assert.Error(t, err)
+		assert.True(t, diags.HasError())
+		_ = host.Close()
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "hello", server.files["/tmp/hello.txt"])
+	assert.Contains(t, server.commands, `chmod 0600 "/tmp/hello.txt"`)
+	// One bastion connection, with every instance session tunneled through it
+	assert.Equal(t, 3, server.tunnels)
+	assert.Equal(t, 4, server.connections)
+}
@@ -0,0 +1,265 @@
+package ch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/go-hsdp-api/cartel"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// detailsBatchSize is the number of hosts to fetch details of per Cartel call
+const detailsBatchSize = 50
+
+// DataSourceContainerHosts returns the details of all container hosts matching a set of filters
+func DataSourceContainerHosts() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceContainerHostsRead,
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"subnet_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"public", "private"}, false),
+			},
+			"vpc": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     tools.StringSchema(),
+			},
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"private_ips": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"hosts": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     containerHostDetailsSchema(),
+			},
+		},
+	}
+}
+
+func containerHostDetailsSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"private_ip": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"public_ip": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"role": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"subnet": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vpc": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"launch_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"block_devices": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"ldap_groups": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"security_groups": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"tags": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"protection": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// containerHostFilter selects hosts by role, subnet, VPC and tags
+type containerHostFilter struct {
+	Role    string
+	Subnets map[string]bool
+	Vpc     string
+	Tags    map[string]string
+}
+
+func (f containerHostFilter) matches(host cartel.InstanceDetails) bool {
+	if f.Role != "" && host.Role != f.Role {
+		return false
+	}
+	if f.Subnets != nil && !f.Subnets[host.Subnet] {
+		return false
+	}
+	if f.Vpc != "" && host.Vpc != f.Vpc {
+		return false
+	}
+	for k, v := range f.Tags {
+		if value, ok := host.Tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// subnetsOfType returns the IDs of the subnets whose name starts with subnetType
+func subnetsOfType(client *cartel.Client, subnetType string) (map[string]bool, error) {
+	subnets, _, err := client.GetAllSubnets()
+	if err != nil {
+		return nil, fmt.Errorf("cartel.GetAllSubnets: %w", err)
+	}
+	ids := make(map[string]bool)
+	for name, subnet := range *subnets {
+		if strings.HasPrefix(name, subnetType) {
+			ids[subnet.ID] = true
+		}
+	}
+	return ids, nil
+}
+
+func dataSourceContainerHostsRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	cfg := m.(*config.Config)
+	client, err := cfg.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	filter := containerHostFilter{
+		Role: d.Get("role").(string),
+		Vpc:  d.Get("vpc").(string),
+		Tags: make(map[string]string),
+	}
+	for k, v := range d.Get("tags").(map[string]interface{}) {
+		filter.Tags[k] = v.(string)
+	}
+	if subnetType := d.Get("subnet_type").(string); subnetType != "" {
+		filter.Subnets, err = subnetsOfType(client, subnetType)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	instances, _, err := client.GetAllInstances()
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("cartel.GetAllInstances: %w", err))
+	}
+	var candidates []string
+	for _, instance := range *instances {
+		// The instance list only has the role, everything else needs the details
+		if filter.Role != "" && instance.Role != "" && instance.Role != filter.Role {
+			continue
+		}
+		candidates = append(candidates, instance.NameTag)
+	}
+	sort.Strings(candidates)
+
+	var hosts []map[string]interface{}
+	var names, privateIPs []string
+	for start := 0; start < len(candidates); start += detailsBatchSize {
+		end := start + detailsBatchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		details, err := existingGroupHosts(client, candidates[start:end])
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("cartel.GetDetailsMulti: %w", err))
+		}
+		for _, name := range candidates[start:end] {
+			host, ok := details[name]
+			if !ok || !filter.matches(host) {
+				continue
+			}
+			names = append(names, name)
+			privateIPs = append(privateIPs, host.PrivateAddress)
+			hosts = append(hosts, map[string]interface{}{
+				"name":            name,
+				"id":              host.InstanceID,
+				"type":            host.InstanceType,
+				"owner":           host.Owner,
+				"private_ip":      host.PrivateAddress,
+				"public_ip":       host.PublicAddress,
+				"state":           host.State,
+				"role":            host.Role,
+				"subnet":          host.Subnet,
+				"vpc":             host.Vpc,
+				"zone":            host.Zone,
+				"launch_time":     host.LaunchTime,
+				"block_devices":   host.BlockDevices,
+				"ldap_groups":     []string(host.LdapGroups),
+				"security_groups": host.SecurityGroups,
+				"tags":            host.Tags,
+				"protection":      host.Protection,
+			})
+		}
+	}
+
+	d.SetId("cartel_hosts")
+	_ = d.Set("names", names)
+	_ = d.Set("private_ips", privateIPs)
+	_ = d.Set("hosts", hosts)
+	return diags
+}
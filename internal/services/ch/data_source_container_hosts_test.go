@@ -0,0 +1,53 @@
+package ch_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestDataSourceContainerHosts_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	webID := fake.AddContainerHost("web-0", map[string]string{"tier": "web"})
+	fake.AddContainerHost("web-1", map[string]string{"tier": "web"})
+	fake.AddInstance("edge-0", "container-host", "public", map[string]string{"tier": "web"})
+	fake.AddContainerHost("db-0", map[string]string{"tier": "db"})
+	fake.AddInstance("vault-0", "vault", "private", map[string]string{"tier": "web"})
+
+	dataSourceName := "data.hsdp_container_hosts.web"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + `
+data "hsdp_container_hosts" "web" {
+  role        = "container-host"
+  subnet_type = "private"
+
+  tags = {
+    tier = "web"
+  }
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "names.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "names.0", "web-0"),
+					resource.TestCheckResourceAttr(dataSourceName, "names.1", "web-1"),
+					resource.TestCheckResourceAttr(dataSourceName, "private_ips.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "hosts.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "hosts.0.id", webID),
+					resource.TestCheckResourceAttr(dataSourceName, "hosts.0.subnet", "subnet-private-1a"),
+					resource.TestCheckResourceAttr(dataSourceName, "hosts.0.tags.tier", "web"),
+					resource.TestCheckResourceAttr(dataSourceName, "hosts.0.block_devices.#", "1"),
+					resource.TestCheckResourceAttr(dataSourceName, "hosts.0.security_groups.0", "base"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "hosts.0.launch_time"),
+				),
+			},
+		},
+	})
+}
@@ -48,6 +48,38 @@ func tagsSchema() *schema.Schema {
 	}
 }
 
+func tagsAllSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeMap,
+		Computed: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// maxTags is the number of tags Cartel supports on a host
+const maxTags = 8
+
+// customizeDiffTags plans tags_all and checks the provider default tags
+// still fit on the host
+func customizeDiffTags(d *schema.ResourceDiff, m interface{}) error {
+	var defaults map[string]string
+	if c, ok := m.(*config.Config); ok {
+		defaults = c.DefaultTags
+	}
+	if err := tools.CustomizeDiffTagsAll(d, defaults, "billing"); err != nil {
+		return err
+	}
+	if all, ok := d.Get("tags_all").(map[string]interface{}); ok && len(all) > maxTags {
+		return fmt.Errorf("maximum of %d tags are supported, including the provider default_tags", maxTags)
+	}
+	return nil
+}
+
+// hostTags returns the tags to create a host with
+func hostTags(d *schema.ResourceData, c *config.Config) map[string]string {
+	return tools.MergeTags(c.DefaultTags, d.Get("tags").(map[string]interface{}))
+}
+
 func validateTags(v interface{}, _ cty.Path) diag.Diagnostics {
 	var diags diag.Diagnostics
 
@@ -55,8 +87,8 @@ func validateTags(v interface{}, _ cty.Path) diag.Diagnostics {
 	if !ok {
 		return diag.FromErr(fmt.Errorf("expected %q to be a map", v))
 	}
-	if len(tagsMap) > 8 {
-		return diag.FromErr(fmt.Errorf("maximum of 8 tags are supported"))
+	if len(tagsMap) > maxTags {
+		return diag.FromErr(fmt.Errorf("maximum of %d tags are supported", maxTags))
 	}
 	for k, v := range tagsMap {
 		if strings.EqualFold(k, "name") {
@@ -85,10 +117,11 @@ func ResourceContainerHost() *schema.Resource {
 		ReadContext:   resourceContainerHostRead,
 		UpdateContext: resourceContainerHostUpdate,
 		DeleteContext: resourceContainerHostDelete,
+		CustomizeDiff: resourceContainerHostDiff,
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(25 * time.Minute),
-			Update: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(25 * time.Minute),
 			Delete: schema.DefaultTimeout(25 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
@@ -131,6 +164,12 @@ func ResourceContainerHost() *schema.Resource {
 				Optional: true,
 				Default:  false,
 			},
+			"desired_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{instanceStateRunning, instanceStateStopped}, false),
+			},
 			"encrypt_volumes": {
 				Type:     schema.TypeBool,
 				Default:  true,
@@ -167,6 +206,28 @@ func ResourceContainerHost() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"bastion_host_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateHostKey,
+			},
+			"host_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateHostKey,
+			},
+			"known_hosts_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"observed_bastion_host_key": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"observed_host_key": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"user": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -181,6 +242,7 @@ func ResourceContainerHost() *schema.Resource {
 				Optional: true,
 				Default:  false,
 			},
+			sshCertificateField: sshCertificateFieldSchema(),
 			"keep_failed_instances": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -250,7 +312,8 @@ func ResourceContainerHost() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
-			"tags": tagsSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
 		},
 		SchemaVersion: 5,
 	}
@@ -337,13 +400,7 @@ func resourceContainerHostCreate(ctx context.Context, d *schema.ResourceData, m
 		subnetType = "private"
 	}
 	subnet := d.Get("subnet").(string)
-	tagList := d.Get("tags").(map[string]interface{})
-	tags := make(map[string]string)
-	for t, v := range tagList {
-		if val, ok := v.(string); ok {
-			tags[t] = val
-		}
-	}
+	tags := hostTags(d, c)
 	// Validation
 	if diags := validateContainerHostSchema(d); len(diags) > 0 {
 		return diags
@@ -364,8 +421,8 @@ func resourceContainerHostCreate(ctx context.Context, d *schema.ResourceData, m
 		if user == "" && !agent {
 			return diag.FromErr(fmt.Errorf("'user' must be set when 'agent = false' and '%s' are set or 'file' blocks are present", commandsField))
 		}
-		if privateKey == "" && !agent {
-			return diag.FromErr(fmt.Errorf("no SSH 'private_key' was set and 'agent = false', authentication will fail after provisioning step"))
+		if _, useCertificate := d.GetOk(sshCertificateField); privateKey == "" && !agent && !useCertificate {
+			return diag.FromErr(fmt.Errorf("no SSH 'private_key' or 'ssh_certificate' block was set and 'agent = false', authentication will fail after provisioning step"))
 		}
 		if agent && !tools.SSHAgentReachable() {
 			return diag.FromErr(fmt.Errorf("'agent = true' but no working 'ssh-agent' socket is advertised in SSH_AUTH_SOCK environment variable"))
@@ -497,41 +554,74 @@ func resourceContainerHostCreate(ctx context.Context, d *schema.ResourceData, m
 		ssh.Bastion.Key = privateKey
 	}
 
-	// Check health of Docker daemon in case of 'container-host' role and file or commands are set
-	if (len(commands) > 0 || len(createFiles) > 0) && instanceRole == "container-host" {
-		if err := ensureContainerHostReady(ssh, c); err != nil {
+	if len(commands) > 0 || len(createFiles) > 0 {
+		certificate, err := provisioningCertificate(d)
+		if err != nil {
+			if !keepFailedInstances {
+				_, _, _ = client.Destroy(tagName)
+				d.SetId("")
+			}
+			return diag.FromErr(err)
+		}
+		bastion, host, err := connectHost(ssh, containerHostKeyPolicy(d), certificate)
+		if bastion != nil {
+			_ = d.Set("observed_bastion_host_key", bastion.observedKey)
+			defer func() {
+				_ = bastion.Close()
+			}()
+		}
+		if err != nil {
 			if !keepFailedInstances {
 				_, _, _ = client.Destroy(tagName)
 				d.SetId("")
 			}
 			return diag.FromErr(fmt.Errorf(
-				"container host instance '%s' was not deemed healthy: %v",
+				"connecting to container host instance '%s': %w",
 				instanceID, err))
 		}
-	}
+		_ = d.Set("observed_host_key", host.observedKey)
 
-	// Create files
-	_, _ = c.Debug("about to copy %d files to remote\n", len(createFiles))
-	if err := copyFiles(ssh, c, createFiles); err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Warning,
-			Summary:  "failed to copy all files",
-			Detail:   fmt.Sprintf("One or more files failed to copy: %v", err),
-		})
-	}
+		// Check health of Docker daemon in case of 'container-host' role
+		if instanceRole == "container-host" {
+			if err := ensureContainerHostReady(host, c); err != nil {
+				if !keepFailedInstances {
+					_, _, _ = client.Destroy(tagName)
+					d.SetId("")
+				}
+				return diag.FromErr(fmt.Errorf(
+					"container host instance '%s' was not deemed healthy: %v",
+					instanceID, err))
+			}
+		}
 
-	// Run commands
-	stdout, errDiags, err := runCommands(commands, ssh, m)
-	if err != nil {
-		return errDiags
+		// Create files
+		_, _ = c.Debug("about to copy %d files to remote\n", len(createFiles))
+		if err := copyFiles(host, c, createFiles); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "failed to copy all files",
+				Detail:   fmt.Sprintf("One or more files failed to copy: %v", err),
+			})
+		}
+
+		// Run commands
+		stdout, errDiags, err := runCommands(commands, host, m)
+		if err != nil {
+			return errDiags
+		}
+		_ = d.Set("result", stdout)
 	}
-	_ = d.Set("result", stdout)
 	d.SetId(instanceID)
+	if d.Get("desired_state").(string) == instanceStateStopped {
+		if err := stopContainerHost(ctx, client, tagName, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+	}
 	readDiags := resourceContainerHostRead(ctx, d, m)
 	return append(diags, readDiags...)
 }
 
-func ensureContainerHostReady(ssh *easyssh.MakeConfig, config *config.Config) error {
+func ensureContainerHostReady(ssh remoteHost, config *config.Config) error {
 	operation := func() error {
 		outStr, errStr, done, err := ssh.Run("docker volume ls") // This command should succeed
 		_, _ = config.Debug("ensureContainerHostReady: %t\nstdout:\n%s\nstderr:\n%s\n", done, outStr, errStr)
@@ -574,7 +664,7 @@ func findInstanceByName(client *cartel.Client, name string) *cartel.InstanceDeta
 	return nil
 }
 
-func copyFiles(ssh *easyssh.MakeConfig, config *config.Config, createFiles []provisionFile) error {
+func copyFiles(ssh remoteHost, config *config.Config, createFiles []provisionFile) error {
 	for _, f := range createFiles {
 		if f.Source != "" {
 			src, srcErr := os.Open(f.Source)
@@ -590,20 +680,20 @@ func copyFiles(ssh *easyssh.MakeConfig, config *config.Config, createFiles []pro
 			}
 			err := ssh.WriteFile(src, srcStat.Size(), f.Destination)
 			if err != nil {
-				_, _ = config.Debug("Error copying %s to remote file %s:%s: %v\n", f.Source, ssh.Server, f.Destination, err)
+				_, _ = config.Debug("Error copying %s to remote file %s:%s: %v\n", f.Source, remoteAddress(ssh), f.Destination, err)
 				return fmt.Errorf("copyFiles: %w", err)
 			}
-			_, _ = config.Debug("Copied %s to remote file %s:%s: %d bytes\n", f.Source, ssh.Server, f.Destination, srcStat.Size())
+			_, _ = config.Debug("Copied %s to remote file %s:%s: %d bytes\n", f.Source, remoteAddress(ssh), f.Destination, srcStat.Size())
 			_ = src.Close()
 		} else {
 			buffer := bytes.NewBufferString(f.Content)
 			// Should we fail the complete provision on errors here?
 			err := ssh.WriteFile(buffer, int64(buffer.Len()), f.Destination)
 			if err != nil {
-				_, _ = config.Debug("Error copying content to remote file %s:%s: %v\n", ssh.Server, f.Destination, err)
+				_, _ = config.Debug("Error copying content to remote file %s:%s: %v\n", remoteAddress(ssh), f.Destination, err)
 				return fmt.Errorf("copyFiles: %w", err)
 			}
-			_, _ = config.Debug("Created remote file %s:%s: %d bytes\n", ssh.Server, f.Destination, len(f.Content))
+			_, _ = config.Debug("Created remote file %s:%s: %d bytes\n", remoteAddress(ssh), f.Destination, len(f.Content))
 		}
 		// Permissions change
 		if f.Permissions != "" {
@@ -701,7 +791,11 @@ func collectFilesToCreate(d *schema.ResourceData) ([]provisionFile, diag.Diagnos
 	return files, diags
 }
 
-func resourceContainerHostUpdate(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceContainerHostDiff(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	return customizeDiffTags(d, m)
+}
+
+func resourceContainerHostUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*config.Config)
 
 	var diags diag.Diagnostics
@@ -733,8 +827,10 @@ func resourceContainerHostUpdate(_ context.Context, d *schema.ResourceData, m in
 		bastionHost = client.BastionHost()
 	}
 
-	if d.HasChange("tags") {
-		o, n := d.GetChange("tags")
+	if d.HasChanges("tags", "tags_all") {
+		// tags_all is unknown at apply when tags were, so compute it again
+		o, _ := d.GetChange("tags_all")
+		n := hostTags(d, c)
 		change := generateTagChange(o, n)
 		log.Printf("[o:%v] [n:%v] [c:%v]\n", o, n, change)
 		_, _, err := client.AddTags([]string{tagName}, change)
@@ -796,6 +892,16 @@ func resourceContainerHostUpdate(_ context.Context, d *schema.ResourceData, m in
 			return diag.FromErr(err)
 		}
 	}
+	desiredState := d.Get("desired_state").(string)
+	if d.HasChange("file") && desiredState == instanceStateStopped {
+		return diag.FromErr(fmt.Errorf("files cannot be provisioned on a stopped container host"))
+	}
+	if d.HasChange("desired_state") && desiredState != "" {
+		_, _ = c.Debug("bringing '%s' to state %s\n", tagName, desiredState)
+		if err := setContainerHostState(ctx, client, tagName, desiredState, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	// Collect SSH details
 	privateIP := d.Get("private_ip").(string)
 	ssh := &easyssh.MakeConfig{
@@ -821,8 +927,23 @@ func resourceContainerHostUpdate(_ context.Context, d *schema.ResourceData, m in
 		if len(diags) > 0 {
 			return diags
 		}
+		certificate, err := provisioningCertificate(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		bastion, host, err := connectHost(ssh, containerHostKeyPolicy(d), certificate)
+		if bastion != nil {
+			_ = d.Set("observed_bastion_host_key", bastion.observedKey)
+			defer func() {
+				_ = bastion.Close()
+			}()
+		}
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("connecting to remote: %w", err))
+		}
+		_ = d.Set("observed_host_key", host.observedKey)
 		_, _ = c.Debug("about to copy %d files to remote\n", len(createFiles))
-		if err := copyFiles(ssh, c, createFiles); err != nil {
+		if err := copyFiles(host, c, createFiles); err != nil {
 			return diag.FromErr(fmt.Errorf("copying files to remote: %w", err))
 		}
 		if commandsAfterFileChanges {
@@ -831,7 +952,7 @@ func resourceContainerHostUpdate(_ context.Context, d *schema.ResourceData, m in
 				return diags
 			}
 			// Run commands
-			stdout, errDiags, err := runCommands(commands, ssh, m)
+			stdout, errDiags, err := runCommands(commands, host, m)
 			if err != nil {
 				return errDiags
 			}
@@ -841,6 +962,15 @@ func resourceContainerHostUpdate(_ context.Context, d *schema.ResourceData, m in
 	return diags
 }
 
+// containerHostKeyPolicy returns the host keys to verify provisioning connections against
+func containerHostKeyPolicy(d *schema.ResourceData) hostKeyPolicy {
+	return hostKeyPolicy{
+		BastionHostKey: d.Get("bastion_host_key").(string),
+		HostKey:        d.Get("host_key").(string),
+		KnownHostsFile: d.Get("known_hosts_file").(string),
+	}
+}
+
 func resourceContainerHostRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*config.Config)
 
@@ -911,7 +1041,13 @@ func resourceContainerHostRead(_ context.Context, d *schema.ResourceData, m inte
 		subnetType = "public"
 	}
 	_ = d.Set("subnet_type", subnetType)
-	_ = d.Set("tags", normalizeTags(ch.Tags))
+	tagsAll := normalizeTags(ch.Tags)
+	_ = d.Set("tags_all", tagsAll)
+	_ = d.Set("tags", tools.StripDefaultTags(tagsAll, c.DefaultTags, d.Get("tags").(map[string]interface{})))
+	// Only settled run states are reported, transitions show up on the next refresh
+	if ch.State == instanceStateRunning || ch.State == instanceStateStopped {
+		_ = d.Set("desired_state", ch.State)
+	}
 
 	return diags
 }
@@ -955,10 +1091,9 @@ func normalizeTags(tags map[string]string) map[string]string {
 	return normalized
 }
 
-func generateTagChange(old, new interface{}) map[string]string {
+func generateTagChange(old interface{}, n map[string]string) map[string]string {
 	change := make(map[string]string)
-	o := old.(map[string]interface{})
-	n := new.(map[string]interface{})
+	o, _ := old.(map[string]interface{})
 	for k := range o {
 		if newVal, ok := n[k]; !ok || newVal == "" {
 			change[k] = ""
@@ -968,14 +1103,12 @@ func generateTagChange(old, new interface{}) map[string]string {
 		if k == "billing" {
 			continue
 		}
-		if s, ok := v.(string); ok {
-			change[k] = s
-		}
+		change[k] = v
 	}
 	return change
 }
 
-func runCommands(commands []string, ssh *easyssh.MakeConfig, m interface{}) (string, diag.Diagnostics, error) {
+func runCommands(commands []string, ssh remoteHost, m interface{}) (string, diag.Diagnostics, error) {
 	var diags diag.Diagnostics
 	var stdout, stderr string
 	var done bool
@@ -0,0 +1,773 @@
+package ch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/loafoe/easyssh-proxy/v2"
+	"github.com/philips-software/go-hsdp-api/cartel"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+	"golang.org/x/sync/errgroup"
+)
+
+// fleetPollInterval is how often the deployment state of a group is polled
+var fleetPollInterval = 10 * time.Second
+
+// ResourceContainerHostGroup manages a fleet of identically configured container
+// hosts named <name_prefix>-<index>. Hosts are created with a single Cartel call
+// and provisioned in parallel over one bastion connection.
+func ResourceContainerHostGroup() *schema.Resource {
+	return &schema.Resource{
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CreateContext: resourceContainerHostGroupCreate,
+		ReadContext:   resourceContainerHostGroupRead,
+		UpdateContext: resourceContainerHostGroupUpdate,
+		DeleteContext: resourceContainerHostGroupDelete,
+		CustomizeDiff: resourceContainerHostGroupDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(45 * time.Minute),
+			Update: schema.DefaultTimeout(45 * time.Minute),
+			Delete: schema.DefaultTimeout(25 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"name_prefix": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+			"instance_count": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntBetween(1, 100),
+			},
+			"parallelism": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      5,
+				ValidateFunc: validation.IntBetween(1, 20),
+			},
+			"instance_role": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "container-host",
+			},
+			"image": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"instance_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "m5.large",
+			},
+			"volume_type": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"iops"},
+			},
+			"iops": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntBetween(1, 4000),
+			},
+			"protect": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"encrypt_volumes": {
+				Type:     schema.TypeBool,
+				Default:  true,
+				Optional: true,
+				ForceNew: true,
+			},
+			"volumes": {
+				Type:         schema.TypeInt,
+				Default:      0,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntBetween(0, 6),
+			},
+			"volume_size": {
+				Type:         schema.TypeInt,
+				Default:      0,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntBetween(0, 16000),
+			},
+			"security_groups": {
+				Type:     schema.TypeSet,
+				MaxItems: 4,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"user_groups": {
+				Type:     schema.TypeSet,
+				MaxItems: 50,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"subnet_type": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Default:       "private",
+				ConflictsWith: []string{"subnet"},
+			},
+			"subnet": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Optional: true,
+			},
+			"bastion_host": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"bastion_host_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateHostKey,
+			},
+			"host_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateHostKey,
+			},
+			"known_hosts_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"observed_bastion_host_key": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"observed_host_keys": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"user": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"private_key": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"agent": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			sshCertificateField: sshCertificateFieldSchema(),
+			"keep_failed_instances": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			commandsField: {
+				Type:     schema.TypeList,
+				MaxItems: 10,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			fileField: {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     fileFieldSchema(),
+			},
+			"hosts": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"instance_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"private_ip": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"public_ip": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"launch_time": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+		},
+	}
+}
+
+func groupHostName(prefix string, index int) string {
+	return fmt.Sprintf("%s-%d", prefix, index)
+}
+
+// groupHostNames returns the host names for indexes from up to, but not including, to
+func groupHostNames(prefix string, from, to int) []string {
+	var names []string
+	for i := from; i < to; i++ {
+		names = append(names, groupHostName(prefix, i))
+	}
+	return names
+}
+
+// withNameTags makes a Create call provision all the given hosts at once
+func withNameTags(names []string) cartel.RequestOptionFunc {
+	return func(body *cartel.RequestBody) error {
+		body.NameTag = names
+		return nil
+	}
+}
+
+func resourceContainerHostGroupDiff(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := customizeDiffTags(d, m); err != nil {
+		return err
+	}
+	if d.Id() == "" || d.HasChange("instance_count") {
+		return nil
+	}
+	// Plan an update when hosts disappeared or hosts above the count remain
+	count := d.Get("instance_count").(int)
+	indexes := stateHostIndexes(d.Get("hosts").([]interface{}))
+	for i := 0; i < count; i++ {
+		if !indexes[i] {
+			return d.SetNewComputed("hosts")
+		}
+	}
+	if len(indexes) != count {
+		return d.SetNewComputed("hosts")
+	}
+	return nil
+}
+
+// stateHostIndexes returns the indexes of the hosts in the hosts attribute
+func stateHostIndexes(hosts []interface{}) map[int]bool {
+	indexes := make(map[int]bool, len(hosts))
+	for _, h := range hosts {
+		if host, ok := h.(map[string]interface{}); ok {
+			indexes[hostIndex(host["name"].(string))] = true
+		}
+	}
+	return indexes
+}
+
+// groupScanCount returns how many host indexes to look up: up to count, or
+// beyond it when the hosts known in state have higher indexes
+func groupScanCount(count int, hosts []interface{}) int {
+	for index := range stateHostIndexes(hosts) {
+		if index >= count {
+			count = index + 1
+		}
+	}
+	return count
+}
+
+// existingGroupHosts returns the details of the named hosts which exist.
+// Cartel rejects the whole request when one of the names does not exist, so
+// a rejected batch is split in halves until only the unknown names are left out.
+func existingGroupHosts(client *cartel.Client, names []string) (map[string]cartel.InstanceDetails, error) {
+	found := map[string]cartel.InstanceDetails{}
+	if len(names) == 0 {
+		return found, nil
+	}
+	details, resp, err := client.GetDetailsMulti(names...)
+	if err == nil {
+		return *details, nil
+	}
+	if resp == nil || resp.StatusCode() != http.StatusBadRequest {
+		return nil, err
+	}
+	if len(names) == 1 {
+		return found, nil
+	}
+	half := len(names) / 2
+	for _, part := range [][]string{names[:half], names[half:]} {
+		details, err := existingGroupHosts(client, part)
+		if err != nil {
+			return nil, err
+		}
+		for name, host := range details {
+			found[name] = host
+		}
+	}
+	return found, nil
+}
+
+func destroyGroupHosts(client *cartel.Client, names []string) error {
+	for _, name := range names {
+		if _, _, err := client.Destroy(name); err != nil {
+			return fmt.Errorf("destroying '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// createGroupHosts creates the named hosts in one Cartel call, waits for all of
+// them to deploy and then provisions them
+func createGroupHosts(ctx context.Context, d *schema.ResourceData, c *config.Config, client *cartel.Client, names []string, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	keepFailedInstances := d.Get("keep_failed_instances").(bool)
+	cleanup := func(err error) diag.Diagnostics {
+		if keepFailedInstances {
+			diags = append(diags, diag.FromErr(fmt.Errorf("'keep_failed_instances' is enabled so not removing %v, remember to destroy them manually", names))...)
+		} else {
+			existing, _ := existingGroupHosts(client, names)
+			for name := range existing {
+				_, _, _ = client.Destroy(name)
+			}
+		}
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	numberOfVolumes := d.Get("volumes").(int)
+	volumeSize := d.Get("volume_size").(int)
+	ch, resp, err := client.Create(names[0],
+		cartel.SecurityGroups(tools.ExpandStringList(d.Get("security_groups").(*schema.Set).List())...),
+		cartel.UserGroups(tools.ExpandStringList(d.Get("user_groups").(*schema.Set).List())...),
+		cartel.VolumeType(d.Get("volume_type").(string)),
+		cartel.IOPs(d.Get("iops").(int)),
+		cartel.InstanceType(d.Get("instance_type").(string)),
+		cartel.VolumesAndSize(numberOfVolumes, volumeSize),
+		cartel.VolumeEncryption(d.Get("encrypt_volumes").(bool)),
+		cartel.Protect(d.Get("protect").(bool)),
+		cartel.InstanceRole(d.Get("instance_role").(string)),
+		cartel.SubnetType(d.Get("subnet_type").(string)),
+		cartel.Tags(hostTags(d, c)),
+		cartel.InSubnet(d.Get("subnet").(string)),
+		cartel.Image(d.Get("image").(string)),
+		withNameTags(names),
+	)
+	if err != nil {
+		// Do not clean up existing hosts
+		if err == cartel.ErrHostnameAlreadyExists {
+			return diag.FromErr(fmt.Errorf("one of the hosts %v already exists: %w", names, err))
+		}
+		if ch != nil && resp != nil {
+			return cleanup(fmt.Errorf("create error (description=[%s], code=[%d]): %w", ch.Description, resp.StatusCode(), err))
+		}
+		return cleanup(fmt.Errorf("create error: %w", err))
+	}
+	// Track the hosts from here on so a failed apply taints the group instead of orphaning them
+	if d.Id() == "" {
+		d.SetId(d.Get("name_prefix").(string))
+	}
+
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{"provisioning", "indeterminate"},
+		Target:     []string{"succeeded"},
+		Refresh:    fleetStateRefreshFunc(client, names, []string{"failed", "terminated", "shutting-down"}),
+		Timeout:    timeout,
+		Delay:      fleetPollInterval,
+		MinTimeout: fleetPollInterval,
+	}
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return cleanup(fmt.Errorf("error waiting for hosts %v to become ready: %w", names, err))
+	}
+	provisionDiags := provisionGroupHosts(ctx, d, c, client, names, true)
+	diags = append(diags, provisionDiags...)
+	if provisionDiags.HasError() {
+		return cleanup(fmt.Errorf("provisioning hosts %v failed", names))
+	}
+	return diags
+}
+
+// provisionGroupHosts copies files to and runs commands on the named hosts,
+// at most parallelism hosts at a time, through a single bastion connection
+func provisionGroupHosts(ctx context.Context, d *schema.ResourceData, c *config.Config, client *cartel.Client, names []string, created bool) diag.Diagnostics {
+	files, diags := collectFilesToCreate(d)
+	if len(diags) > 0 {
+		return diags
+	}
+	commands, diags := tools.CollectList(commandsField, d)
+	if len(diags) > 0 {
+		return diags
+	}
+	if len(names) == 0 || (len(files) == 0 && len(commands) == 0) {
+		return diags
+	}
+	details, err := existingGroupHosts(client, names)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	bastionHost := d.Get("bastion_host").(string)
+	if bastionHost == "" {
+		bastionHost = client.BastionHost()
+	}
+	user := d.Get("user").(string)
+	privateKey := d.Get("private_key").(string)
+	certificate, err := provisioningCertificate(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	bastion, err := dialBastion(&easyssh.MakeConfig{
+		User:  user,
+		Key:   privateKey,
+		Port:  "22",
+		Proxy: http.ProxyFromEnvironment,
+		Bastion: easyssh.DefaultConfig{
+			User:   user,
+			Key:    privateKey,
+			Server: bastionHost,
+			Port:   "22",
+		},
+	}, containerHostKeyPolicy(d), certificate)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer func() {
+		_ = bastion.Close()
+	}()
+	_ = d.Set("observed_bastion_host_key", bastion.observedKey)
+	observedKeys := make(map[string]interface{})
+	for name, key := range d.Get("observed_host_keys").(map[string]interface{}) {
+		observedKeys[name] = key
+	}
+	defer func() {
+		_ = d.Set("observed_host_keys", observedKeys)
+	}()
+
+	checkReady := created && d.Get("instance_role").(string) == "container-host"
+	var mu sync.Mutex
+	g, _ := errgroup.WithContext(ctx)
+	g.SetLimit(d.Get("parallelism").(int))
+	for _, name := range names {
+		name := name
+		privateIP := details[name].PrivateAddress
+		g.Go(func() error {
+			host, err := bastion.host(privateIP)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			mu.Lock()
+			observedKeys[name] = host.observedKey
+			mu.Unlock()
+			defer func() {
+				_ = host.Close()
+			}()
+			if checkReady {
+				if err := ensureContainerHostReady(host, c); err != nil {
+					return fmt.Errorf("%s was not deemed healthy: %w", name, err)
+				}
+			}
+			if err := copyFiles(host, c, files); err != nil {
+				return fmt.Errorf("%s: copying files to remote: %w", name, err)
+			}
+			_, cmdDiags, err := runCommands(commands, host, c)
+			if err != nil {
+				mu.Lock()
+				diags = append(diags, cmdDiags...)
+				mu.Unlock()
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+func validateContainerHostGroupSSH(d *schema.ResourceData) diag.Diagnostics {
+	_, hasFiles := d.GetOk(fileField)
+	_, hasCommands := d.GetOk(commandsField)
+	if !hasFiles && !hasCommands {
+		return nil
+	}
+	agent := d.Get("agent").(bool)
+	if d.Get("user").(string) == "" {
+		return diag.FromErr(fmt.Errorf("'user' must be set when '%s' are set or 'file' blocks are present", commandsField))
+	}
+	if _, useCertificate := d.GetOk(sshCertificateField); d.Get("private_key").(string) == "" && !agent && !useCertificate {
+		return diag.FromErr(fmt.Errorf("no SSH 'private_key' or 'ssh_certificate' block was set and 'agent = false', authentication will fail after provisioning step"))
+	}
+	if agent && !tools.SSHAgentReachable() {
+		return diag.FromErr(fmt.Errorf("'agent = true' but no working 'ssh-agent' socket is advertised in SSH_AUTH_SOCK environment variable"))
+	}
+	return nil
+}
+
+func resourceContainerHostGroupCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+	client, err := c.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if diags := validateContainerHostSchema(d); len(diags) > 0 {
+		return diags
+	}
+	if diags := validateContainerHostGroupSSH(d); len(diags) > 0 {
+		return diags
+	}
+	prefix := d.Get("name_prefix").(string)
+	names := groupHostNames(prefix, 0, d.Get("instance_count").(int))
+	diags := createGroupHosts(ctx, d, c, client, names, d.Timeout(schema.TimeoutCreate))
+	if diags.HasError() {
+		return diags
+	}
+	return append(diags, resourceContainerHostGroupRead(ctx, d, m)...)
+}
+
+func resourceContainerHostGroupRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	prefix := d.Id()
+	count := d.Get("instance_count").(int)
+	var names []string
+	if count == 0 { // This is an import, discover the hosts by name
+		instances, _, err := client.GetAllInstances()
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("cartel.GetAllInstances: %w", err))
+		}
+		pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `-(\d+)$`)
+		highest := -1
+		for _, i := range *instances {
+			if match := pattern.FindStringSubmatch(i.NameTag); match != nil {
+				if index, _ := strconv.Atoi(match[1]); index > highest {
+					highest = index
+				}
+			}
+		}
+		names = groupHostNames(prefix, 0, highest+1)
+		_ = d.Set("name_prefix", prefix)
+		_ = d.Set("instance_count", highest+1)
+	} else {
+		names = groupHostNames(prefix, 0, groupScanCount(count, d.Get("hosts").([]interface{})))
+	}
+	existing, err := existingGroupHosts(client, names)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(existing) == 0 {
+		d.SetId("")
+		return diags
+	}
+	found := make([]string, 0, len(existing))
+	for name := range existing {
+		found = append(found, name)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return hostIndex(found[i]) < hostIndex(found[j])
+	})
+	hosts := make([]map[string]interface{}, 0, len(found))
+	for _, name := range found {
+		host := existing[name]
+		hosts = append(hosts, map[string]interface{}{
+			"name":        name,
+			"instance_id": host.InstanceID,
+			"private_ip":  host.PrivateAddress,
+			"public_ip":   host.PublicAddress,
+			"launch_time": host.LaunchTime,
+		})
+	}
+	// Missing hosts are planned for recreation by resourceContainerHostGroupDiff
+	_ = d.Set("hosts", hosts)
+	first := existing[found[0]]
+	_ = d.Set("protect", first.Protection)
+	_ = d.Set("security_groups", tools.Difference(first.SecurityGroups, []string{"base"}))
+	_ = d.Set("user_groups", first.LdapGroups)
+	tagsAll := normalizeTags(first.Tags)
+	_ = d.Set("tags_all", tagsAll)
+	_ = d.Set("tags", tools.StripDefaultTags(tagsAll, c.DefaultTags, d.Get("tags").(map[string]interface{})))
+	return diags
+}
+
+// hostIndex returns the index suffix of a group host name
+func hostIndex(name string) int {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '-' {
+			index, _ := strconv.Atoi(name[i+1:])
+			return index
+		}
+	}
+	return 0
+}
+
+func resourceContainerHostGroupUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if diags := validateContainerHostSchema(d); len(diags) > 0 {
+		return diags
+	}
+	if diags := validateContainerHostGroupSSH(d); len(diags) > 0 {
+		return diags
+	}
+	prefix := d.Id()
+	o, n := d.GetChange("instance_count")
+	oldHosts, _ := d.GetChange("hosts")
+	oldCount := groupScanCount(o.(int), oldHosts.([]interface{}))
+	newCount := n.(int)
+	names := groupHostNames(prefix, 0, newCount)
+
+	existing, err := existingGroupHosts(client, names)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var missing, present []string
+	for _, name := range names {
+		if _, ok := existing[name]; ok {
+			present = append(present, name)
+			continue
+		}
+		missing = append(missing, name)
+	}
+	if newCount < oldCount {
+		removed, err := existingGroupHosts(client, groupHostNames(prefix, newCount, oldCount))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		var toDestroy []string
+		for name := range removed {
+			toDestroy = append(toDestroy, name)
+		}
+		if err := destroyGroupHosts(client, toDestroy); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if len(present) > 0 {
+		if d.HasChanges("tags", "tags_all") {
+			o, _ := d.GetChange("tags_all")
+			if _, _, err := client.AddTags(present, generateTagChange(o, hostTags(d, c))); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+		for _, groups := range []struct {
+			field  string
+			add    func([]string, []string) error
+			remove func([]string, []string) error
+		}{
+			{
+				field: "user_groups",
+				add: func(i, g []string) error {
+					_, _, err := client.AddUserGroups(i, g)
+					return err
+				},
+				remove: func(i, g []string) error {
+					_, _, err := client.RemoveUserGroups(i, g)
+					return err
+				},
+			},
+			{
+				field: "security_groups",
+				add: func(i, g []string) error {
+					_, _, err := client.AddSecurityGroups(i, g)
+					return err
+				},
+				remove: func(i, g []string) error {
+					_, _, err := client.RemoveSecurityGroups(i, g)
+					return err
+				},
+			},
+		} {
+			if !d.HasChange(groups.field) {
+				continue
+			}
+			o, n := d.GetChange(groups.field)
+			old := tools.ExpandStringList(o.(*schema.Set).List())
+			newEntries := tools.ExpandStringList(n.(*schema.Set).List())
+			if toRemove := tools.Difference(old, newEntries); len(toRemove) > 0 {
+				if err := groups.remove(present, toRemove); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+			if toAdd := tools.Difference(newEntries, old); len(toAdd) > 0 {
+				if err := groups.add(present, toAdd); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+		if d.HasChange("protect") {
+			protect := d.Get("protect").(bool)
+			for _, name := range present {
+				if _, _, err := client.SetProtection(name, protect); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+		if d.HasChanges(fileField, commandsField) {
+			diags = append(diags, provisionGroupHosts(ctx, d, c, client, present, false)...)
+			if diags.HasError() {
+				return diags
+			}
+		}
+	}
+	if len(missing) > 0 {
+		diags = append(diags, createGroupHosts(ctx, d, c, client, missing, d.Timeout(schema.TimeoutUpdate))...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+	return append(diags, resourceContainerHostGroupRead(ctx, d, m)...)
+}
+
+func resourceContainerHostGroupDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	count := groupScanCount(d.Get("instance_count").(int), d.Get("hosts").([]interface{}))
+	existing, err := existingGroupHosts(client, groupHostNames(d.Id(), 0, count))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var names []string
+	for name := range existing {
+		names = append(names, name)
+	}
+	if err := destroyGroupHosts(client, names); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return diags
+}
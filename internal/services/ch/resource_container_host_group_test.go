@@ -0,0 +1,126 @@
+package ch_test
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestResourceContainerHostGroup_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_container_host_group.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceContainerHostGroup(3),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "hosts.#", "3"),
+					resource.TestCheckResourceAttr(resourceName, "hosts.2.name", "web-2"),
+					resource.TestCheckResourceAttr(resourceName, "tags.team", "fleet"),
+					func(_ *terraform.State) error {
+						// The whole fleet is created with a single Cartel call
+						if n := fake.Requests(http.MethodPost, "/v3/api/create"); n != 1 {
+							return fmt.Errorf("expected 1 create call, got %d", n)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testResourceContainerHostGroup(5),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "hosts.#", "5"),
+					resource.TestCheckResourceAttr(resourceName, "hosts.4.name", "web-4"),
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testResourceContainerHostGroup(2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "hosts.#", "2"),
+					func(_ *terraform.State) error {
+						if state := fake.ContainerHostState("web-2"); state != "" {
+							return fmt.Errorf("expected web-2 to be destroyed, it is %s", state)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// A host that disappears is recreated without lowering the count
+				PreConfig: func() {
+					fake.RemoveContainerHost("web-0")
+				},
+				Config: fake.ProviderConfig() + testResourceContainerHostGroup(2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "instance_count", "2"),
+					resource.TestCheckResourceAttr(resourceName, "hosts.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "hosts.0.name", "web-0"),
+				),
+			},
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"parallelism", "keep_failed_instances", "agent", "instance_role", "instance_type", "encrypt_volumes", "volumes", "volume_size", "subnet_type"},
+			},
+		},
+	})
+}
+
+func TestResourceContainerHostGroupProvisioningFailure_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + `
+resource "hsdp_container_host_group" "test" {
+  name_prefix    = "broken"
+  instance_count = 2
+  user           = "core"
+  private_key    = "not a key"
+  commands       = ["true"]
+}`,
+				ExpectError: regexp.MustCompile(`provisioning hosts \[broken-0 broken-1\] failed`),
+			},
+			{
+				PreConfig: func() {
+					// Hosts which failed to provision are not left behind
+					for _, name := range []string{"broken-0", "broken-1"} {
+						if state := fake.ContainerHostState(name); state != "" {
+							t.Errorf("expected %s to be destroyed, it is %s", name, state)
+						}
+					}
+				},
+				Config: fake.ProviderConfig(),
+			},
+		},
+	})
+}
+
+func testResourceContainerHostGroup(count int) string {
+	return fmt.Sprintf(`
+resource "hsdp_container_host_group" "test" {
+  name_prefix    = "web"
+  instance_count = %d
+
+  tags = {
+    team = "fleet"
+  }
+}`, count)
+}
@@ -0,0 +1,115 @@
+package ch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/philips-software/go-hsdp-api/cartel"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+)
+
+// ResourceContainerHostPower reboots a container host whenever it is created,
+// so changing its triggers performs a one-off reboot
+func ResourceContainerHostPower() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceContainerHostPowerCreate,
+		ReadContext:   resourceContainerHostPowerRead,
+		DeleteContext: resourceContainerHostPowerDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(25 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"instance_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceContainerHostPowerCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	tagName := d.Get("name").(string)
+	ch, _, err := client.GetDetails(tagName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("container host '%s': %w", tagName, err))
+	}
+	_, _ = c.Debug("rebooting '%s'\n", tagName)
+	err = rebootContainerHost(ctx, client, tagName, d.Timeout(schema.TimeoutCreate))
+	switch {
+	case errors.Is(err, errHostStopped):
+		// Starting it would fight the desired_state of the host
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "container host not rebooted",
+			Detail:   fmt.Sprintf("'%s' is stopped, so it was left stopped instead of being rebooted", tagName),
+		})
+	case err != nil:
+		return diag.FromErr(err)
+	}
+	d.SetId(ch.InstanceID)
+	return append(diags, resourceContainerHostPowerRead(ctx, d, m)...)
+}
+
+func resourceContainerHostPowerRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.CartelClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ch, resp, err := client.GetDetails(d.Get("name").(string))
+	if err != nil {
+		if errors.Is(err, cartel.ErrNotFound) || (resp != nil && resp.StatusCode() == http.StatusBadRequest) {
+			d.SetId("")
+			return diags
+		}
+		return diag.FromErr(err)
+	}
+	if ch.InstanceID != d.Id() {
+		// The host was replaced, so this reboot no longer applies
+		d.SetId("")
+		return diags
+	}
+	_ = d.Set("instance_id", ch.InstanceID)
+	_ = d.Set("state", ch.State)
+	return diags
+}
+
+func resourceContainerHostPowerDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	d.SetId("")
+	return diags
+}
@@ -0,0 +1,63 @@
+package ch_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestResourceContainerHostPower_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	instanceID := fake.AddContainerHost("host", nil)
+	resourceName := "hsdp_container_host_power.test"
+
+	reboots := func(n int) resource.TestCheckFunc {
+		return func(_ *terraform.State) error {
+			if got := fake.Requests(http.MethodPost, "/v3/api/suspend"); got != n {
+				return fmt.Errorf("expected %d reboots, got %d", n, got)
+			}
+			return nil
+		}
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceContainerHostPower("1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "instance_id", instanceID),
+					resource.TestCheckResourceAttr(resourceName, "state", "running"),
+					reboots(1),
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testResourceContainerHostPower("1"),
+				Check:  reboots(1),
+			},
+			{
+				Config: fake.ProviderConfig() + testResourceContainerHostPower("2"),
+				Check:  reboots(2),
+			},
+		},
+	})
+}
+
+func testResourceContainerHostPower(trigger string) string {
+	return fmt.Sprintf(`
+resource "hsdp_container_host_power" "test" {
+  name = "host"
+
+  triggers = {
+    reboot = %q
+  }
+}`, trigger)
+}
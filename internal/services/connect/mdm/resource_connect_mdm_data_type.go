@@ -21,6 +21,7 @@ func ResourceConnectMDMDataType() *schema.Resource {
 		ReadContext:   resourceConnectMDMDataTypeRead,
 		UpdateContext: resourceConnectMDMDataTypeUpdate,
 		DeleteContext: resourceConnectMDMDataTypeDelete,
+		CustomizeDiff: resourceConnectMDMDataTypeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -43,6 +44,11 @@ func ResourceConnectMDMDataType() *schema.Resource {
 				Optional: true,
 				Elem:     tools.StringSchema(),
 			},
+			"tags_all": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
 			"version_id": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -55,11 +61,19 @@ func ResourceConnectMDMDataType() *schema.Resource {
 	}
 }
 
-func schemaToDataType(d *schema.ResourceData) mdm.DataType {
+func resourceConnectMDMDataTypeDiff(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	var defaults map[string]string
+	if c, ok := m.(*config.Config); ok {
+		defaults = c.DefaultTags
+	}
+	return tools.CustomizeDiffStringTagsAll(d, "tags", "tags_all", defaults)
+}
+
+func schemaToDataType(d *schema.ResourceData, defaultTags map[string]string) mdm.DataType {
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
 	propositionId := d.Get("proposition_id").(string)
-	tags := tools.ExpandStringList(d.Get("tags").(*schema.Set).List())
+	tags := tools.MergeStringTags(defaultTags, tools.ExpandStringList(d.Get("tags").(*schema.Set).List()))
 
 	resource := mdm.DataType{
 		Name:          name,
@@ -70,11 +84,13 @@ func schemaToDataType(d *schema.ResourceData) mdm.DataType {
 	return resource
 }
 
-func dataTypeToSchema(resource mdm.DataType, d *schema.ResourceData) {
+func dataTypeToSchema(resource mdm.DataType, d *schema.ResourceData, defaultTags map[string]string) {
+	configured := tools.ExpandStringList(d.Get("tags").(*schema.Set).List())
 	_ = d.Set("name", resource.Name)
 	_ = d.Set("description", resource.Description)
 	_ = d.Set("name", resource.Name)
-	_ = d.Set("tags", tools.SchemaSetStrings(resource.Tags))
+	_ = d.Set("tags", tools.SchemaSetStrings(tools.StripDefaultStringTags(resource.Tags, defaultTags, configured)))
+	_ = d.Set("tags_all", tools.SchemaSetStrings(resource.Tags))
 	_ = d.Set("guid", resource.ID)
 	_ = d.Set("proposition_id", resource.PropositionId.Reference)
 }
@@ -87,7 +103,7 @@ func resourceConnectMDMDataTypeCreate(ctx context.Context, d *schema.ResourceDat
 		return diag.FromErr(err)
 	}
 
-	resource := schemaToDataType(d)
+	resource := schemaToDataType(d, c.DefaultTags)
 
 	var created *mdm.DataType
 	var resp *mdm.Response
@@ -145,7 +161,7 @@ func resourceConnectMDMDataTypeRead(ctx context.Context, d *schema.ResourceData,
 		}
 		return diag.FromErr(err)
 	}
-	dataTypeToSchema(*resource, d)
+	dataTypeToSchema(*resource, d, c.DefaultTags)
 	return diags
 }
 
@@ -161,7 +177,7 @@ func resourceConnectMDMDataTypeUpdate(ctx context.Context, d *schema.ResourceDat
 
 	id := d.Get("guid").(string)
 
-	service := schemaToDataType(d)
+	service := schemaToDataType(d, c.DefaultTags)
 	service.ID = id
 
 	_, _, err = client.DataTypes.Update(service)
@@ -105,6 +105,10 @@ func resourceDockerNamespaceCreate(ctx context.Context, d *schema.ResourceData,
 			if !errors.As(err, &graphql.Errors{}) {
 				return backoff.Permanent(err)
 			}
+			// HTTP failures already went through the console client retry policy
+			if errors.As(err, &graphql.NetworkError{}) {
+				return backoff.Permanent(err)
+			}
 			//gqlErr := err.(graphql.Errors)
 			return err
 		}
@@ -183,6 +183,9 @@ func resourceFunctionUpdate(_ context.Context, d *schema.ResourceData, m interfa
 		var resp *iron.Response
 		operation := func() error {
 			_, resp, err = ironClient.Codes.CreateOrUpdateCode(*code)
+			if err != nil && resp != nil && tools.RetriesExhausted(resp.Response) {
+				return backoff.Permanent(err)
+			}
 			return err
 		}
 		err = backoff.Retry(operation, backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 8))
@@ -280,6 +283,9 @@ func resourceFunctionCreate(ctx context.Context, d *schema.ResourceData, m inter
 			Image:     dockerImage,
 			ProjectID: ironConfig.ProjectID,
 		})
+		if err != nil && resp != nil && tools.RetriesExhausted(resp.Response) {
+			return backoff.Permanent(err)
+		}
 		return err
 	}
 	err = backoff.Retry(operation, backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 8))
@@ -631,7 +637,7 @@ func newIronClient(d *schema.ResourceData, m interface{}) (*iron.Client, *iron.C
 		ProjectID: cfg["project_id"],
 		Token:     cfg["token"],
 		UserID:    cfg["user_id"],
-		DebugLog:  c.DebugWriter,
+		DebugLog:  c.LogWriter(config.LogFunction),
 		ClusterInfo: []iron.ClusterInfo{
 			{
 				ClusterID:   cfg["cluster_info_0_cluster_id"],
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMApplication_basic(t *testing.T) {
@@ -35,6 +36,35 @@ func TestAccResourceIAMApplication_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMApplication_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_application.test"
+	config := fake.ProviderConfig() + testAccResourceIAMApplication(fake.RootOrgID, "fake")
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", "ACC-FAKE"),
+					resource.TestCheckResourceAttrPair(resourceName, "proposition_id", "hsdp_iam_proposition.test", "id"),
+				),
+			},
+			{
+				Config:                  config,
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"wait_for_delete"},
+			},
+		},
+	})
+}
+
 func testAccResourceIAMApplication(parentOrgID, name string) string {
 	// We create a completely separate ORG as that is currently
 	// the only way we can clean up Propositions and Applications
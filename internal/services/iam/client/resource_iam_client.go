@@ -55,11 +55,27 @@ func ResourceIAMClient() *schema.Resource {
 				Description:      "The client id",
 			},
 			"password": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Sensitive:   true,
-				ForceNew:    true,
-				Description: "The password to use (8-16 chars, at least one capital, number, special char).",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"password", "password_wo"},
+				Description:  "The password to use (8-16 chars, at least one capital, number, special char).",
+			},
+			"password_wo": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				WriteOnly:    true,
+				ExactlyOneOf: []string{"password", "password_wo"},
+				Description:  "Write-only variant of `password` which is never stored in state. Requires Terraform 1.11 or newer.",
+			},
+			"password_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"password_wo"},
+				Description:  "Version of `password_wo`. Changing this value recreates the client with the new password.",
 			},
 			"description": {
 				Type:        schema.TypeString,
@@ -160,7 +176,11 @@ func resourceIAMClientCreate(ctx context.Context, d *schema.ResourceData, m inte
 	cl.ClientID = d.Get("client_id").(string)
 	cl.Type = d.Get("type").(string)
 	cl.GlobalReferenceID = d.Get("global_reference_id").(string)
-	cl.Password = d.Get("password").(string)
+	password, diags := tools.SecretString(d, "password", "password_wo")
+	if diags.HasError() {
+		return diags
+	}
+	cl.Password = password
 	cl.Name = d.Get("name").(string)
 	cl.RedirectionURIs = tools.ExpandStringList(d.Get("redirection_uris").(*schema.Set).List())
 	cl.ResponseTypes = tools.ExpandStringList(d.Get("response_types").(*schema.Set).List())
@@ -190,7 +210,9 @@ func resourceIAMClientCreate(ctx context.Context, d *schema.ResourceData, m inte
 		return diag.FromErr(err)
 	}
 	d.SetId(createdClient.ID)
-	_ = d.Set("password", cl.Password)
+	if d.Get("password").(string) != "" {
+		_ = d.Set("password", cl.Password)
+	}
 	return resourceIAMClientRead(ctx, d, m)
 }
 
@@ -7,7 +7,9 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
 )
 
@@ -50,6 +52,35 @@ func TestAccResourceIAMClient_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMClient_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_client.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccResourceIAMClient(fake.RootOrgID, "Confidential", "fake", "fake-1", "ACCFOO", "Fake-Passw0rd!"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "client_id", "acc-fake"),
+					resource.TestCheckResourceAttr(resourceName, "description", "ACCFOO"),
+					resource.TestCheckResourceAttr(resourceName, "scopes.#", "4"),
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testAccResourceIAMClient(fake.RootOrgID, "Confidential", "fake", "fake-2", "ACCBAR", "Fake-Passw0rd!"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", "fake-2"),
+					resource.TestCheckResourceAttr(resourceName, "description", "ACCBAR"),
+				),
+			},
+		},
+	})
+}
+
 func testAccResourceIAMClient(parentOrgID, clientType, fixedName, changingName, description, randomPassword string) string {
 	// We create a completely separate ORG as that is currently
 	// the only way we can clean up Propositions and Applications
@@ -118,3 +149,75 @@ resource "hsdp_iam_client" "test" {
 		description,
 	)
 }
+
+func TestResourceIAMClient_passwordWO(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_client.test"
+	var clientID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceIAMClientPasswordWO("First-Passw0rd!", 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "password_wo"),
+					resource.TestCheckResourceAttr(resourceName, "password", ""),
+					resource.TestCheckResourceAttr(resourceName, "password_wo_version", "1"),
+					func(s *terraform.State) error {
+						clientID = s.RootModule().Resources[resourceName].Primary.ID
+						if password := fake.ClientPassword(clientID); password != "First-Passw0rd!" {
+							return fmt.Errorf("unexpected client password %q", password)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// A new value alone is not detected, as it never reaches the plan
+				Config:   fake.ProviderConfig() + testResourceIAMClientPasswordWO("Second-Passw0rd!", 1),
+				PlanOnly: true,
+			},
+			{
+				// Bumping the version recreates the client with the new password
+				Config: fake.ProviderConfig() + testResourceIAMClientPasswordWO("Second-Passw0rd!", 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "password_wo"),
+					resource.TestCheckResourceAttr(resourceName, "password_wo_version", "2"),
+					func(s *terraform.State) error {
+						id := s.RootModule().Resources[resourceName].Primary.ID
+						if id == clientID {
+							return fmt.Errorf("expected the client to be recreated")
+						}
+						if password := fake.ClientPassword(id); password != "Second-Passw0rd!" {
+							return fmt.Errorf("unexpected client password %q", password)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testResourceIAMClientPasswordWO(password string, version int) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_client" "test" {
+  type                = "Confidential"
+  name                = "write-only"
+  client_id           = "write-only"
+  password_wo         = "%s"
+  password_wo_version = %d
+  application_id      = "9a4b0e3e-8c36-4d0e-9a47-3a0f6c0f3a41"
+  global_reference_id = "678477ff-35cb-4999-9100-0e74a16b820b"
+  description         = "Write-only password"
+  scopes              = ["cn"]
+  default_scopes      = ["cn"]
+  redirection_uris    = ["https://foo.bar/auth"]
+  response_types      = ["code"]
+}
+`, password, version)
+}
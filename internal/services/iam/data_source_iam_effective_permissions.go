@@ -0,0 +1,167 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+)
+
+// DataSourceIAMEffectivePermissions resolves the permissions a principal holds
+// in an organization through its groups and their roles
+func DataSourceIAMEffectivePermissions() *schema.Resource {
+	return &schema.Resource{
+		Description: "Computes the permissions a user, service or device holds in an organization and which group and role grant them.",
+		ReadContext: dataSourceIAMEffectivePermissionsRead,
+		Schema: map[string]*schema.Schema{
+			"principal_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"principal_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{"USER", "SERVICE", "DEVICE"}, false),
+			},
+			"organization_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"permissions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"grants": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"permission": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"group_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"group_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"role_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"role_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// permissionGrant records through which group and role a permission is held
+type permissionGrant struct {
+	Permission string
+	Group      iam.GroupResource
+	Role       iam.Role
+}
+
+// effectiveGrants walks the groups of a principal in orgID, their roles and
+// the permissions of those roles
+func effectiveGrants(client *iam.Client, principalType, principalID, orgID string) ([]permissionGrant, error) {
+	groups, _, err := client.Groups.GetGroups(&iam.GetGroupOptions{
+		OrganizationID: &orgID,
+		MemberType:     &principalType,
+		MemberID:       &principalID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing groups of %s: %w", principalID, err)
+	}
+	var grants []permissionGrant
+	rolePermissions := make(map[string][]string)
+	for _, group := range *groups {
+		roles, _, err := client.Roles.GetRolesByGroupID(group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing roles of group %s: %w", group.GroupName, err)
+		}
+		for _, role := range *roles {
+			permissions, ok := rolePermissions[role.ID]
+			if !ok {
+				list, _, err := client.Roles.GetRolePermissions(role)
+				if err != nil {
+					return nil, fmt.Errorf("listing permissions of role %s: %w", role.Name, err)
+				}
+				if list != nil {
+					permissions = *list
+				}
+				rolePermissions[role.ID] = permissions
+			}
+			for _, permission := range permissions {
+				grants = append(grants, permissionGrant{Permission: permission, Group: group, Role: role})
+			}
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		if a.Permission != b.Permission {
+			return a.Permission < b.Permission
+		}
+		if a.Group.GroupName != b.Group.GroupName {
+			return a.Group.GroupName < b.Group.GroupName
+		}
+		return a.Role.Name < b.Role.Name
+	})
+	return grants, nil
+}
+
+func dataSourceIAMEffectivePermissionsRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c := meta.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	principalID := d.Get("principal_id").(string)
+	principalType := d.Get("principal_type").(string)
+	orgID := d.Get("organization_id").(string)
+
+	grants, err := effectiveGrants(client, principalType, principalID, orgID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	permissions := make([]string, 0)
+	list := make([]map[string]interface{}, 0, len(grants))
+	for _, grant := range grants {
+		if len(permissions) == 0 || permissions[len(permissions)-1] != grant.Permission {
+			permissions = append(permissions, grant.Permission)
+		}
+		list = append(list, map[string]interface{}{
+			"permission": grant.Permission,
+			"group_id":   grant.Group.ID,
+			"group_name": grant.Group.GroupName,
+			"role_id":    grant.Role.ID,
+			"role_name":  grant.Role.Name,
+			"path":       grant.Group.GroupName + "/" + grant.Role.Name,
+		})
+	}
+	d.SetId(orgID + "/" + principalID)
+	_ = d.Set("permissions", permissions)
+	_ = d.Set("grants", list)
+	return diags
+}
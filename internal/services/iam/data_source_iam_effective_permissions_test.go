@@ -0,0 +1,89 @@
+package iam_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestDataSourceIAMEffectivePermissions_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	for _, permission := range []string{"GROUP.READ", "PATIENT.READ", "PATIENT.WRITE"} {
+		fake.AddPermission(permission)
+	}
+	userID := fake.AddUser("auditee", "Passw0rd!fake", fake.RootOrgID)
+	dataSourceName := "data.hsdp_iam_effective_permissions.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testDataSourceIAMEffectivePermissions(fake.RootOrgID, userID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "permissions.#", "3"),
+					resource.TestCheckResourceAttr(dataSourceName, "permissions.0", "GROUP.READ"),
+					resource.TestCheckResourceAttr(dataSourceName, "grants.#", "6"),
+					resource.TestCheckResourceAttr(dataSourceName, "grants.0.path", "auditors/READER"),
+					resource.TestCheckResourceAttr(dataSourceName, "grants.4.path", "clinicians/WRITER"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "grants.4.role_id", "hsdp_iam_role.writer", "id"),
+					resource.TestCheckResourceAttr(dataSourceName, "grants.5.permission", "PATIENT.WRITE"),
+				),
+			},
+		},
+	})
+}
+
+func testDataSourceIAMEffectivePermissions(orgID, userID string) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_role" "reader" {
+  name                  = "READER"
+  description           = "Reader"
+  managing_organization = "%[1]s"
+  permissions           = ["PATIENT.READ", "GROUP.READ"]
+}
+
+resource "hsdp_iam_role" "writer" {
+  name                  = "WRITER"
+  description           = "Writer"
+  managing_organization = "%[1]s"
+  permissions           = ["PATIENT.READ", "PATIENT.WRITE"]
+}
+
+resource "hsdp_iam_group" "clinicians" {
+  name                  = "clinicians"
+  description           = "Clinicians"
+  managing_organization = "%[1]s"
+  roles                 = [hsdp_iam_role.reader.id, hsdp_iam_role.writer.id]
+  users                 = ["%[2]s"]
+}
+
+resource "hsdp_iam_group" "auditors" {
+  name                  = "auditors"
+  description           = "Auditors"
+  managing_organization = "%[1]s"
+  roles                 = [hsdp_iam_role.reader.id]
+  users                 = ["%[2]s"]
+}
+
+resource "hsdp_iam_group" "other" {
+  name                  = "other"
+  description           = "Not a member"
+  managing_organization = "%[1]s"
+  roles                 = [hsdp_iam_role.writer.id]
+}
+
+data "hsdp_iam_effective_permissions" "test" {
+  principal_id    = "%[2]s"
+  principal_type  = "USER"
+  organization_id = "%[1]s"
+
+  depends_on = [hsdp_iam_group.clinicians, hsdp_iam_group.auditors, hsdp_iam_group.other]
+}
+`, orgID, userID)
+}
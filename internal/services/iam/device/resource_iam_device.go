@@ -37,10 +37,25 @@ func ResourceIAMDevice() *schema.Resource {
 				Description: "The login id of the device.",
 			},
 			"password": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Sensitive:   true,
-				Description: "The password of the device.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ExactlyOneOf: []string{"password", "password_wo"},
+				Description:  "The password of the device.",
+			},
+			"password_wo": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				WriteOnly:    true,
+				ExactlyOneOf: []string{"password", "password_wo"},
+				Description:  "Write-only variant of `password` which is never stored in state. Requires Terraform 1.11 or newer.",
+			},
+			"password_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				RequiredWith: []string{"password_wo"},
+				Description:  "Version of `password_wo`. Change this value to update the device password in place.",
 			},
 			"type": {
 				Type:     schema.TypeString,
@@ -268,6 +283,13 @@ func resourceIAMDeviceUpdate(ctx context.Context, d *schema.ResourceData, m inte
 	}
 	device := schemaToDevice(d)
 	device.ID = d.Id()
+	if d.HasChange("password_wo_version") {
+		password, diags := tools.WriteOnlyString(d, "password_wo")
+		if diags.HasError() {
+			return diags
+		}
+		device.Password = password
+	}
 
 	var updatedDevice *iam.Device
 	var resp *iam.Response
@@ -342,6 +364,13 @@ func resourceIAMDeviceCreate(ctx context.Context, d *schema.ResourceData, m inte
 	}
 
 	device := schemaToDevice(d)
+	if device.Password == "" {
+		password, diags := tools.WriteOnlyString(d, "password_wo")
+		if diags.HasError() {
+			return diags
+		}
+		device.Password = password
+	}
 
 	if device.GlobalReferenceID == "" {
 		result, err := uuid.GenerateUUID()
@@ -7,7 +7,9 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
 )
 
@@ -36,6 +38,28 @@ func TestAccResourceIAMDevice_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMDevice_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_device.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccResourceIAMUser(fake.RootOrgID, "fakedevice", "Fake-Passw0rd!"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "login_id", "fakedevice"),
+					resource.TestCheckResourceAttr(resourceName, "type", "ActivityMonitor"),
+					resource.TestCheckResourceAttr(resourceName, "external_identifier.0.value", "fakedevice"),
+				),
+			},
+		},
+	})
+}
+
 func testAccResourceIAMUser(parentOrgID, name, randomPassword string) string {
 	upperName := strings.ToUpper(name)
 
@@ -97,3 +121,76 @@ resource "hsdp_iam_device" "test" {
 		randomPassword,
 		name)
 }
+
+func TestResourceIAMDevice_passwordWO(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_device.test"
+	var deviceID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceIAMDevicePasswordWO(fake.RootOrgID, "First-Passw0rd!", 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "password_wo"),
+					resource.TestCheckResourceAttr(resourceName, "password", ""),
+					resource.TestCheckResourceAttr(resourceName, "password_wo_version", "1"),
+					func(s *terraform.State) error {
+						deviceID = s.RootModule().Resources[resourceName].Primary.ID
+						if password := fake.DevicePassword(deviceID); password != "First-Passw0rd!" {
+							return fmt.Errorf("unexpected device password %q", password)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// Bumping the version updates the password of the same device
+				Config: fake.ProviderConfig() + testResourceIAMDevicePasswordWO(fake.RootOrgID, "Second-Passw0rd!", 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "password_wo"),
+					resource.TestCheckResourceAttr(resourceName, "password_wo_version", "2"),
+					func(s *terraform.State) error {
+						id := s.RootModule().Resources[resourceName].Primary.ID
+						if id != deviceID {
+							return fmt.Errorf("expected the device to be updated in place")
+						}
+						if password := fake.DevicePassword(id); password != "Second-Passw0rd!" {
+							return fmt.Errorf("unexpected device password %q", password)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testResourceIAMDevicePasswordWO(orgID, password string, version int) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_device" "test" {
+  login_id            = "writeonly"
+  password_wo         = "%s"
+  password_wo_version = %d
+
+  organization_id = "%s"
+  application_id  = "9a4b0e3e-8c36-4d0e-9a47-3a0f6c0f3a41"
+
+  external_identifier {
+    type {
+      code = "ID"
+      text = "Device Identifier"
+    }
+    system = "https://www.philips.co.id/phs/healthwatch"
+    value  = "writeonly"
+  }
+
+  type     = "ActivityMonitor"
+  for_test = true
+}
+`, password, version, orgID)
+}
@@ -0,0 +1,176 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+)
+
+var (
+	_ ephemeral.EphemeralResourceWithConfigure = (*iamTokenEphemeralResource)(nil)
+	_ ephemeral.EphemeralResourceWithConfigure = (*iamPrincipalTokenEphemeralResource)(nil)
+)
+
+// NewIAMTokenEphemeralResource returns the hsdp_iam_token ephemeral resource,
+// the access token of the provider credentials which is never persisted
+func NewIAMTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &iamTokenEphemeralResource{}
+}
+
+// NewIAMPrincipalTokenEphemeralResource returns the hsdp_iam_principal_token
+// ephemeral resource, which logs in as the given principal
+func NewIAMPrincipalTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &iamPrincipalTokenEphemeralResource{}
+}
+
+type iamTokenModel struct {
+	AccessToken types.String `tfsdk:"access_token"`
+	ExpiresAt   types.Int64  `tfsdk:"expires_at"`
+	IDToken     types.String `tfsdk:"id_token"`
+}
+
+type iamPrincipalTokenModel struct {
+	Principal   *config.PrincipalModel `tfsdk:"principal"`
+	AccessToken types.String           `tfsdk:"access_token"`
+	ExpiresAt   types.Int64            `tfsdk:"expires_at"`
+	IDToken     types.String           `tfsdk:"id_token"`
+}
+
+func tokenAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"access_token": schema.StringAttribute{
+			Computed:    true,
+			Sensitive:   true,
+			Description: "The IAM access token",
+		},
+		"expires_at": schema.Int64Attribute{
+			Computed:    true,
+			Description: "The expiry time of the access token in Unix seconds",
+		},
+		"id_token": schema.StringAttribute{
+			Computed:    true,
+			Sensitive:   true,
+			Description: "The OpenID Connect ID token, if any",
+		},
+	}
+}
+
+func configureEphemeral(req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) *config.Config {
+	if req.ProviderData == nil {
+		return nil
+	}
+	c, ok := req.ProviderData.(*config.Config)
+	if !ok {
+		resp.Diagnostics.AddError("unexpected provider data",
+			fmt.Sprintf("expected *config.Config, got %T", req.ProviderData))
+		return nil
+	}
+	return c
+}
+
+// tokenOf returns a valid access token of client, refreshing it when needed
+func tokenOf(client *iam.Client) (iamTokenModel, error) {
+	token, err := client.Token()
+	if err != nil {
+		return iamTokenModel{}, err
+	}
+	return iamTokenModel{
+		AccessToken: types.StringValue(token),
+		ExpiresAt:   types.Int64Value(client.Expires()),
+		IDToken:     types.StringValue(client.IDToken()),
+	}, nil
+}
+
+type iamTokenEphemeralResource struct {
+	config *config.Config
+}
+
+func (r *iamTokenEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_iam_token"
+}
+
+func (r *iamTokenEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Access token of the provider IAM credentials. The token is never stored in plan or state.",
+		Attributes:  tokenAttributes(),
+	}
+}
+
+func (r *iamTokenEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.config = configureEphemeral(req, resp)
+}
+
+func (r *iamTokenEphemeralResource) Open(ctx context.Context, _ ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	client, err := r.config.IAMClient()
+	if err != nil {
+		resp.Diagnostics.AddError("error creating IAM client", err.Error())
+		return
+	}
+	token, err := tokenOf(client)
+	if err != nil {
+		resp.Diagnostics.AddError("error retrieving IAM token", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &token)...)
+}
+
+type iamPrincipalTokenEphemeralResource struct {
+	config *config.Config
+}
+
+func (r *iamPrincipalTokenEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_iam_principal_token"
+}
+
+func (r *iamPrincipalTokenEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Access token of an IAM user or service principal. The token is never stored in plan or state.",
+		Attributes:  tokenAttributes(),
+		Blocks: map[string]schema.Block{
+			"principal": config.PrincipalEphemeralBlock(),
+		},
+	}
+}
+
+func (r *iamPrincipalTokenEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.config = configureEphemeral(req, resp)
+}
+
+func (r *iamPrincipalTokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data iamPrincipalTokenModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	principal := config.ModelToPrincipal(data.Principal, r.config)
+	if principal.Username == "" && principal.ServiceID == "" {
+		resp.Diagnostics.AddError("missing principal credentials",
+			"principal requires either username and password or service_id and service_private_key")
+		return
+	}
+	if !principal.HasAuth() {
+		resp.Diagnostics.AddError("incomplete principal credentials",
+			"principal requires a password or service_private_key")
+		return
+	}
+	client, err := r.config.IAMClient(principal)
+	if err != nil {
+		resp.Diagnostics.AddError("error logging in as principal", err.Error())
+		return
+	}
+	token, err := tokenOf(client)
+	if err != nil {
+		resp.Diagnostics.AddError("error retrieving IAM token", err.Error())
+		return
+	}
+	data.AccessToken = token.AccessToken
+	data.ExpiresAt = token.ExpiresAt
+	data.IDToken = token.IDToken
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
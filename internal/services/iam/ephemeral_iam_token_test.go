@@ -0,0 +1,74 @@
+package iam_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestEphemeralIAMToken_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	_, serviceID, privateKey := fake.AddService("token", fake.RootOrgID)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		ProtoV5ProviderFactories: acc.ProtoV5ProviderFactories,
+		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+			"echo": echoprovider.NewProviderServer(),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testEphemeralIAMToken(serviceID, privateKey),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("echo.token", tfjsonpath.New("data").AtMapKey("access_token"),
+						knownvalue.StringRegexp(regexp.MustCompile(`.+`))),
+					statecheck.ExpectKnownValue("echo.principal", tfjsonpath.New("data").AtMapKey("access_token"),
+						knownvalue.StringRegexp(regexp.MustCompile(`.+`))),
+				},
+			},
+		},
+	})
+}
+
+func testEphemeralIAMToken(serviceID, privateKey string) string {
+	return fmt.Sprintf(`
+ephemeral "hsdp_iam_token" "test" {}
+
+ephemeral "hsdp_iam_principal_token" "test" {
+  principal {
+    service_id          = %q
+    service_private_key = %q
+  }
+}
+
+provider "echo" {
+  data = ephemeral.hsdp_iam_token.test
+}
+
+provider "echo" {
+  alias = "principal"
+  data  = ephemeral.hsdp_iam_principal_token.test
+}
+
+resource "echo" "token" {}
+
+resource "echo" "principal" {
+  provider = echo.principal
+}
+`, serviceID, privateKey)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
 )
 
@@ -39,6 +40,32 @@ func TestAccResourceIAMGroup_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMGroup_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	fake.AddPermission("ALL.READ")
+	fake.AddPermission("ALL.WRITE")
+	resourceName := "hsdp_iam_group.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccResourceIAMGroup(fake.RootOrgID, "fake", "Fake-Passw0rd!"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "devices.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "users.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "services.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "roles.#", "1"),
+					resource.TestCheckResourceAttr("hsdp_iam_group.user_test", "devices.#", "0"),
+				),
+			},
+		},
+	})
+}
+
 func testAccResourceIAMGroup(parentOrgID, name, password string) string {
 	upperName := strings.ToUpper(name)
 
@@ -0,0 +1,124 @@
+package group_membership
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+const (
+	// memberPageSize is the largest page of group members IAM returns
+	memberPageSize = 100
+	// memberBatchSize is the number of members added or removed per retried batch
+	memberBatchSize = 100
+)
+
+// memberAction adds or removes members of one type from a group
+type memberAction func(g *iam.GroupsService, ctx context.Context, group iam.Group, ids ...string) (iam.MemberResponse, *iam.Response, error)
+
+// memberKind describes how members of one type are managed
+type memberKind struct {
+	field      string
+	memberType string
+	add        memberAction
+	remove     memberAction
+}
+
+var (
+	userMembers = memberKind{
+		field:      "users",
+		memberType: iam.GroupMemberTypeUser,
+		add:        (*iam.GroupsService).AddMembers,
+		remove:     (*iam.GroupsService).RemoveMembers,
+	}
+	serviceMembers = memberKind{
+		field:      "services",
+		memberType: iam.GroupMemberTypeService,
+		add:        (*iam.GroupsService).AddServices,
+		remove:     (*iam.GroupsService).RemoveServices,
+	}
+	deviceMembers = memberKind{
+		field:      "devices",
+		memberType: iam.GroupMemberTypeDevice,
+		add:        (*iam.GroupsService).AddDevices,
+		remove:     (*iam.GroupsService).RemoveDevices,
+	}
+	memberKinds = []memberKind{userMembers, serviceMembers, deviceMembers}
+)
+
+// groupMembers returns all members of memberType in a group, reading them page by page.
+// groupMembersStartIndex is the 1-based SCIM index of the first member of a page.
+// Member IDs are deduplicated so members shifting between pages are not reported twice.
+func groupMembers(ctx context.Context, client *iam.Client, groupID, memberType string) ([]string, error) {
+	var members []string
+	seen := make(map[string]bool)
+	for page := 1; ; page++ {
+		var scimGroup *iam.SCIMGroup
+		err := tools.TryHTTPCall(ctx, 5, func() (*http.Response, error) {
+			pageSize := memberPageSize
+			startIndex := (page-1)*memberPageSize + 1
+			var resp *iam.Response
+			var err error
+			scimGroup, resp, err = client.Groups.SCIMGetGroupByID(groupID, &iam.SCIMGetGroupOptions{
+				IncludeGroupMembersType: &memberType,
+				GroupMembersCount:       &pageSize,
+				GroupMembersStartIndex:  &startIndex,
+			})
+			if resp == nil {
+				return nil, err
+			}
+			return resp.Response, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if scimGroup == nil {
+			return nil, fmt.Errorf("empty response reading page %d", page)
+		}
+		resources := scimGroup.ExtensionGroup.GroupMembers.Resources
+		added := 0
+		for _, r := range resources {
+			if seen[r.ID] {
+				continue
+			}
+			seen[r.ID] = true
+			members = append(members, r.ID)
+			added++
+		}
+		if added == 0 || len(members) >= scimGroup.ExtensionGroup.GroupMembers.TotalResults {
+			return members, nil
+		}
+	}
+}
+
+// applyMembers runs action for ids in batches, retrying each batch on its own
+func applyMembers(ctx context.Context, client *iam.Client, group iam.Group, action memberAction, ids []string) error {
+	for start := 0; start < len(ids); start += memberBatchSize {
+		end := start + memberBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+		err := tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
+			result, resp, err := action(client.Groups, ctx, group, batch...)
+			if err != nil {
+				_ = client.TokenRefresh()
+			}
+			if resp == nil {
+				return nil, err
+			}
+			if !(resp.StatusCode() == http.StatusOK || resp.StatusCode() == http.StatusMultiStatus) {
+				return resp.Response, backoff.Permanent(fmt.Errorf("%v %w", result, err))
+			}
+			return resp.Response, err
+		})
+		if err != nil {
+			return fmt.Errorf("members %d-%d of %d: %w", start+1, end, len(ids), err)
+		}
+	}
+	return nil
+}
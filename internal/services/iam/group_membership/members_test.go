@@ -0,0 +1,41 @@
+package group_membership
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMembersPaging(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client, err := iam.NewClient(nil, &iam.Config{
+		Region:         "us-east",
+		Environment:    "client-test",
+		OAuth2ClientID: fakehsdp.ClientID,
+		OAuth2Secret:   fakehsdp.ClientPassword,
+		IAMURL:         fake.URL,
+		IDMURL:         fake.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	group, _, err := client.Groups.CreateGroup(iam.Group{
+		Name:                 "members",
+		ManagingOrganization: fake.RootOrgID,
+	})
+	require.NoError(t, err)
+	var users []string
+	for i := 0; i <= 2*memberPageSize; i++ {
+		users = append(users, fake.AddUser(fmt.Sprintf("user%03d", i), "Passw0rd!", fake.RootOrgID))
+	}
+	fake.AddGroupMembers(group.ID, iam.GroupMemberTypeUser, users...)
+
+	members, err := groupMembers(context.Background(), client, group.ID, iam.GroupMemberTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, users, members)
+}
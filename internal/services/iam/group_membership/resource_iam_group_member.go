@@ -0,0 +1,145 @@
+package group_membership
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// ResourceIAMGroupUser attaches a single user to a group
+func ResourceIAMGroupUser() *schema.Resource {
+	return resourceIAMGroupMember(userMembers, "user_id")
+}
+
+// ResourceIAMGroupService attaches a single service identity to a group
+func ResourceIAMGroupService() *schema.Resource {
+	return resourceIAMGroupMember(serviceMembers, "service_id")
+}
+
+// ResourceIAMGroupDevice attaches a single device to a group
+func ResourceIAMGroupDevice() *schema.Resource {
+	return resourceIAMGroupMember(deviceMembers, "device_id")
+}
+
+// resourceIAMGroupMember manages the membership of one principal of kind,
+// identified by principalField, in a group. The ID is group_id/principal_id.
+func resourceIAMGroupMember(kind memberKind, principalField string) *schema.Resource {
+	return &schema.Resource{
+		Importer: &schema.ResourceImporter{
+			StateContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+				groupID, principalID, ok := strings.Cut(d.Id(), "/")
+				if !ok || groupID == "" || principalID == "" {
+					return nil, fmt.Errorf("expecting group_id/%s as import string", principalField)
+				}
+				_ = d.Set("group_id", groupID)
+				_ = d.Set(principalField, principalID)
+				return []*schema.ResourceData{d}, nil
+			},
+		},
+		CreateContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			return resourceIAMGroupMemberCreate(ctx, d, m, kind, principalField)
+		},
+		ReadContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			return resourceIAMGroupMemberRead(ctx, d, m, kind, principalField)
+		},
+		DeleteContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			return resourceIAMGroupMemberDelete(ctx, d, m, kind, principalField)
+		},
+
+		Schema: map[string]*schema.Schema{
+			"group_id": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateFunc:     validation.StringIsNotEmpty,
+				DiffSuppressFunc: tools.SuppressCaseDiffs,
+			},
+			principalField: {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateFunc:     validation.StringIsNotEmpty,
+				DiffSuppressFunc: tools.SuppressCaseDiffs,
+			},
+		},
+	}
+}
+
+func resourceIAMGroupMemberCreate(ctx context.Context, d *schema.ResourceData, m interface{}, kind memberKind, principalField string) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	group, err := getGroup(client, d.Get("group_id").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	principalID := d.Get(principalField).(string)
+	if err := applyMembers(ctx, client, *group, kind.add, []string{principalID}); err != nil {
+		return diag.FromErr(fmt.Errorf("error adding %s '%s' to group: %w", strings.ToLower(kind.memberType), principalID, err))
+	}
+	d.SetId(group.ID + "/" + principalID)
+	return resourceIAMGroupMemberRead(ctx, d, m, kind, principalField)
+}
+
+func resourceIAMGroupMemberRead(ctx context.Context, d *schema.ResourceData, m interface{}, kind memberKind, principalField string) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	groupID := d.Get("group_id").(string)
+	principalID := d.Get(principalField).(string)
+	_, resp, err := client.Groups.GetGroupByID(groupID)
+	if err != nil {
+		if resp != nil && resp.StatusCode() == http.StatusNotFound {
+			d.SetId("")
+			return diags
+		}
+		return diag.FromErr(err)
+	}
+	members, err := groupMembers(ctx, client, groupID, kind.memberType)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error retrieving %s from group: %w", kind.field, err))
+	}
+	for _, member := range members {
+		if strings.EqualFold(member, principalID) {
+			return diags
+		}
+	}
+	// Removed outside of Terraform
+	d.SetId("")
+	return diags
+}
+
+func resourceIAMGroupMemberDelete(ctx context.Context, d *schema.ResourceData, m interface{}, kind memberKind, principalField string) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var group iam.Group
+	group.ID = d.Get("group_id").(string)
+	principalID := d.Get(principalField).(string)
+	if err := applyMembers(ctx, client, group, kind.remove, []string{principalID}); err != nil {
+		return diag.FromErr(fmt.Errorf("error removing %s '%s' from group: %w", strings.ToLower(kind.memberType), principalID, err))
+	}
+	d.SetId("")
+	return diags
+}
@@ -0,0 +1,87 @@
+package group_membership_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestResourceIAMGroupMember_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	userID := fake.AddUser("fakeuser", "Passw0rd!fake", fake.RootOrgID)
+	deviceID := fake.AddDevice("fakedevice", fake.RootOrgID)
+	config := fake.ProviderConfig() + testResourceIAMGroupMember(fake.RootOrgID, userID, deviceID)
+	var groupID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hsdp_iam_group_user.test", "user_id", userID),
+					resource.TestCheckResourceAttr("hsdp_iam_group_device.test", "device_id", deviceID),
+					func(s *terraform.State) error {
+						groupID = s.RootModule().Resources["hsdp_iam_group.test"].Primary.ID
+						if id := s.RootModule().Resources["hsdp_iam_group_user.test"].Primary.ID; id != groupID+"/"+userID {
+							return fmt.Errorf("unexpected ID: %s", id)
+						}
+						if members := fake.GroupMembers(groupID, "USER"); len(members) != 1 || members[0] != userID {
+							return fmt.Errorf("unexpected users: %v", members)
+						}
+						if members := fake.GroupMembers(groupID, "DEVICE"); len(members) != 1 || members[0] != deviceID {
+							return fmt.Errorf("unexpected devices: %v", members)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName: "hsdp_iam_group_user.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(_ *terraform.State) (string, error) {
+					return groupID + "/" + userID, nil
+				},
+				ImportStateVerify: true,
+			},
+			{
+				// Member removed outside Terraform must be planned for re-adding
+				PreConfig: func() {
+					fake.RemoveGroupMembers(groupID, "USER", userID)
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testResourceIAMGroupMember(parentOrgID, userID, deviceID string) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_group" "test" {
+  name                  = "test-group"
+  managing_organization = "%s"
+  description           = "Single member attachments"
+
+  drift_detection = false
+}
+
+resource "hsdp_iam_group_user" "test" {
+  group_id = hsdp_iam_group.test.id
+  user_id  = "%s"
+}
+
+resource "hsdp_iam_group_device" "test" {
+  group_id  = hsdp_iam_group.test.id
+  device_id = "%s"
+}
+`, parentOrgID, userID, deviceID)
+}
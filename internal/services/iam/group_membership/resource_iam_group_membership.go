@@ -5,16 +5,23 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/cenkalti/backoff/v4"
 	"github.com/google/uuid"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/philips-software/go-hsdp-api/iam"
 	"github.com/philips-software/terraform-provider-hsdp/internal/config"
 	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
 )
 
+const (
+	// modeAdditive only manages the listed members
+	modeAdditive = "additive"
+	// modeAuthoritative also removes members which are not listed
+	modeAuthoritative = "authoritative"
+)
+
 func ResourceIAMGroupMembership() *schema.Resource {
 	return &schema.Resource{
 		Importer: &schema.ResourceImporter{
@@ -32,15 +39,24 @@ func ResourceIAMGroupMembership() *schema.Resource {
 				ForceNew:         true,
 				DiffSuppressFunc: tools.SuppressCaseDiffs,
 			},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      modeAdditive,
+				ValidateFunc: validation.StringInSlice([]string{modeAdditive, modeAuthoritative}, false),
+			},
 			"users": {
 				Type:     schema.TypeSet,
-				MaxItems: 2000,
 				Optional: true,
 				Elem:     tools.StringSchema(),
 			},
 			"services": {
 				Type:     schema.TypeSet,
-				MaxItems: 2000,
+				Optional: true,
+				Elem:     tools.StringSchema(),
+			},
+			"devices": {
+				Type:     schema.TypeSet,
 				Optional: true,
 				Elem:     tools.StringSchema(),
 			},
@@ -48,19 +64,8 @@ func ResourceIAMGroupMembership() *schema.Resource {
 	}
 }
 
-func resourceIAMGroupMembershipCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*config.Config)
-
-	var diags diag.Diagnostics
-
-	client, err := c.IAMClient()
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	groupId := d.Get("iam_group_id").(string)
-
+func getGroup(client *iam.Client, groupId string) (*iam.Group, error) {
 	group, resp, err := client.Groups.GetGroupByID(groupId)
-
 	if err != nil {
 		if resp != nil && resp.StatusCode() != http.StatusOK {
 			switch resp.StatusCode() {
@@ -70,54 +75,57 @@ func resourceIAMGroupMembershipCreate(ctx context.Context, d *schema.ResourceDat
 				err = fmt.Errorf("error reading group '%s' (HTTP %d): %w", groupId, resp.StatusCode(), err)
 			}
 		}
-		return diag.FromErr(err)
+		return nil, err
 	}
-	// Add users
-	users := tools.ExpandStringList(d.Get("users").(*schema.Set).List())
-	if len(users) > 0 {
-		err = tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
-			result, resp, err := client.Groups.AddMembers(ctx, *group, users...)
+	return group, nil
+}
+
+// reconcileMembers brings the members of each kind in line with the
+// configuration. In additive mode only changes to the listed members are
+// applied, in authoritative mode members which are not listed are removed.
+func reconcileMembers(ctx context.Context, d *schema.ResourceData, client *iam.Client, group iam.Group) error {
+	authoritative := d.Get("mode").(string) == modeAuthoritative
+	for _, kind := range memberKinds {
+		o, n := d.GetChange(kind.field)
+		old := tools.ExpandStringList(o.(*schema.Set).List())
+		desired := tools.ExpandStringList(n.(*schema.Set).List())
+		if authoritative {
+			current, err := groupMembers(ctx, client, group.ID, kind.memberType)
 			if err != nil {
-				_ = client.TokenRefresh()
+				return fmt.Errorf("error retrieving %s from group: %w", kind.field, err)
 			}
-			if resp == nil {
-				return nil, err
+			old = current
+		}
+		if toRemove := tools.Difference(old, desired); len(toRemove) > 0 {
+			if err := applyMembers(ctx, client, group, kind.remove, toRemove); err != nil {
+				return fmt.Errorf("error removing %s: %w", kind.field, err)
 			}
-			if !(resp.StatusCode() == http.StatusOK || resp.StatusCode() == http.StatusMultiStatus) {
-				return resp.Response, backoff.Permanent(fmt.Errorf("failed to add members: %v %w", result, err))
+		}
+		if toAdd := tools.Difference(desired, old); len(toAdd) > 0 {
+			if err := applyMembers(ctx, client, group, kind.add, toAdd); err != nil {
+				return fmt.Errorf("error adding %s: %w", kind.field, err)
 			}
-			return resp.Response, err
-		})
-		if err != nil {
-			return diag.FromErr(fmt.Errorf("error adding users: %w", err))
 		}
 	}
+	return nil
+}
 
-	// Add services
-	services := tools.ExpandStringList(d.Get("services").(*schema.Set).List())
-	if len(services) > 0 {
-		err = tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
-			result, resp, err := client.Groups.AddServices(ctx, *group, services...)
-			if err != nil {
-				_ = client.TokenRefresh()
-			}
-			if resp == nil {
-				return nil, err
-			}
-			if err != nil {
-				return resp.Response, err
-			}
-			if !(resp.StatusCode() == http.StatusOK || resp.StatusCode() == http.StatusMultiStatus) {
-				return resp.Response, backoff.Permanent(fmt.Errorf("failed to add services: %v", result))
-			}
-			return resp.Response, err
-		})
-		if err != nil {
-			return diag.FromErr(fmt.Errorf("error adding services: %v", err))
-		}
+func resourceIAMGroupMembershipCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	group, err := getGroup(client, d.Get("iam_group_id").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := reconcileMembers(ctx, d, client, *group); err != nil {
+		return diag.FromErr(err)
 	}
 	d.SetId(uuid.NewString())
-	return diags
+	return resourceIAMGroupMembershipRead(ctx, d, m)
 }
 
 func resourceIAMGroupMembershipDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -129,57 +137,14 @@ func resourceIAMGroupMembershipDelete(ctx context.Context, d *schema.ResourceDat
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	groupId := d.Get("iam_group_id").(string)
-
-	group, resp, err := client.Groups.GetGroupByID(groupId)
-
+	group, err := getGroup(client, d.Get("iam_group_id").(string))
 	if err != nil {
-		if resp != nil && resp.StatusCode() != http.StatusOK {
-			switch resp.StatusCode() {
-			case http.StatusForbidden:
-				err = fmt.Errorf("no permission to read group details: %w", err)
-			default:
-				err = fmt.Errorf("error reading group '%s' (HTTP %d): %w", groupId, resp.StatusCode(), err)
-			}
-		}
 		return diag.FromErr(err)
 	}
-	// Remove users
-	users := tools.ExpandStringList(d.Get("users").(*schema.Set).List())
-	if len(users) > 0 {
-		err = tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
-			result, resp, err := client.Groups.RemoveMembers(ctx, *group, users...)
-			if resp == nil {
-				return nil, err
-			}
-			if !(resp.StatusCode() == http.StatusOK || resp.StatusCode() == http.StatusMultiStatus) {
-				return resp.Response, backoff.Permanent(fmt.Errorf("failed to remove members: %v %w", result, err))
-			}
-			return resp.Response, err
-		})
-		if err != nil {
-			return diag.FromErr(fmt.Errorf("error removing users: %w", err))
-		}
-	}
-
-	// Remove services
-	services := tools.ExpandStringList(d.Get("services").(*schema.Set).List())
-	if len(services) > 0 {
-		err = tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
-			result, resp, err := client.Groups.RemoveServices(ctx, *group, services...)
-			if resp == nil {
-				return nil, err
-			}
-			if err != nil {
-				return resp.Response, err
-			}
-			if !(resp.StatusCode() == http.StatusOK || resp.StatusCode() == http.StatusMultiStatus) {
-				return resp.Response, backoff.Permanent(fmt.Errorf("failed to remove services: %v", result))
-			}
-			return resp.Response, err
-		})
-		if err != nil {
-			return diag.FromErr(fmt.Errorf("error removing services: %v", err))
+	for _, kind := range memberKinds {
+		members := tools.ExpandStringList(d.Get(kind.field).(*schema.Set).List())
+		if err := applyMembers(ctx, client, *group, kind.remove, members); err != nil {
+			return diag.FromErr(fmt.Errorf("error removing %s: %w", kind.field, err))
 		}
 	}
 	d.SetId("")
@@ -189,70 +154,20 @@ func resourceIAMGroupMembershipDelete(ctx context.Context, d *schema.ResourceDat
 func resourceIAMGroupMembershipUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*config.Config)
 
-	var diags diag.Diagnostics
-
 	client, err := c.IAMClient()
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	groupId := d.Get("iam_group_id").(string)
-
 	var group iam.Group
-	group.ID = groupId
+	group.ID = d.Get("iam_group_id").(string)
 
-	// Users
-	if d.HasChange("users") {
-		o, n := d.GetChange("users")
-		old := tools.ExpandStringList(o.(*schema.Set).List())
-		newList := tools.ExpandStringList(n.(*schema.Set).List())
-		toAdd := tools.Difference(newList, old)
-		toRemove := tools.Difference(old, newList)
-
-		if len(toRemove) > 0 {
-			_, _, _ = client.Groups.RemoveMembers(ctx, group, toRemove...)
-		}
-		if len(toAdd) > 0 {
-			_, _, _ = client.Groups.AddMembers(ctx, group, toAdd...)
-		}
-	}
-
-	// Services
-	if d.HasChange("services") {
-		o, n := d.GetChange("services")
-		old := tools.ExpandStringList(o.(*schema.Set).List())
-		newList := tools.ExpandStringList(n.(*schema.Set).List())
-		toAdd := tools.Difference(newList, old)
-		toRemove := tools.Difference(old, newList)
-
-		if len(toRemove) > 0 {
-			err = tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
-				_, resp, err := client.Groups.RemoveServices(ctx, group, toRemove...)
-				if resp == nil {
-					return nil, err
-				}
-				return resp.Response, err
-			})
-			if err != nil {
-				diags = append(diags, diag.FromErr(err)...)
-			}
-		}
-		if len(toAdd) > 0 {
-			err = tools.TryHTTPCall(ctx, 10, func() (*http.Response, error) {
-				_, resp, err := client.Groups.AddServices(ctx, group, toAdd...)
-				if resp == nil {
-					return nil, err
-				}
-				return resp.Response, err
-			})
-			if err != nil {
-				diags = append(diags, diag.FromErr(err)...)
-			}
-		}
+	if err := reconcileMembers(ctx, d, client, group); err != nil {
+		return diag.FromErr(err)
 	}
-	return diags
+	return resourceIAMGroupMembershipRead(ctx, d, m)
 }
 
-func resourceIAMGroupMembershipRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceIAMGroupMembershipRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*config.Config)
 
 	var diags diag.Diagnostics
@@ -271,43 +186,24 @@ func resourceIAMGroupMembershipRead(_ context.Context, d *schema.ResourceData, m
 		}
 		return diag.FromErr(err)
 	}
+	authoritative := d.Get("mode").(string) == modeAuthoritative
 
-	// Users
-	// We only deal with users we know
-	users, _, err := client.Users.GetAllUsers(&iam.GetUserOptions{
-		GroupID: &group.ID,
-	})
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("error retrieving users from group: %v", err))
-	}
-	var presentUsers []string
-	knownUsers := tools.ExpandStringList(d.Get("users").(*schema.Set).List())
-	for _, u := range knownUsers {
-		if tools.ContainsString(users, u) {
-			presentUsers = append(presentUsers, u)
-		}
-	}
-	_ = d.Set("users", tools.SchemaSetStrings(presentUsers))
-
-	// Services
-	// We only deal with services we know
-	var verifiedServices []string
-	services := tools.ExpandStringList(d.Get("services").(*schema.Set).List())
-	for _, service := range services {
-		groups, _, err := client.Groups.GetGroups(&iam.GetGroupOptions{
-			MemberType: tools.String("SERVICE"),
-			MemberID:   &service,
-		})
-		if err != nil || groups == nil {
-			continue
+	for _, kind := range memberKinds {
+		members, err := groupMembers(ctx, client, group.ID, kind.memberType)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error retrieving %s from group: %w", kind.field, err))
 		}
-		for _, g := range *groups {
-			if g.ID == group.ID {
-				verifiedServices = append(verifiedServices, service)
-				continue
+		if !authoritative {
+			// We only deal with members we know
+			var known []string
+			for _, member := range tools.ExpandStringList(d.Get(kind.field).(*schema.Set).List()) {
+				if tools.ContainsString(members, member) {
+					known = append(known, member)
+				}
 			}
+			members = known
 		}
+		_ = d.Set(kind.field, tools.SchemaSetStrings(members))
 	}
-	_ = d.Set("services", tools.SchemaSetStrings(verifiedServices))
 	return diags
 }
@@ -8,7 +8,9 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMGroupMembership_basic(t *testing.T) {
@@ -36,6 +38,125 @@ func TestAccResourceIAMGroupMembership_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMGroupMembership_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_group_membership.test"
+	config := fake.ProviderConfig() + testAccResourceIAMUser(fake.RootOrgID, "fakeuser", "Passw0rd!fake")
+	var groupID, userID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "users.#", "1"),
+					resource.TestCheckResourceAttrPair(resourceName, "iam_group_id", "hsdp_iam_group.test", "id"),
+					func(s *terraform.State) error {
+						groupID = s.RootModule().Resources["hsdp_iam_group.test"].Primary.ID
+						userID = s.RootModule().Resources["hsdp_iam_user.test"].Primary.ID
+						if members := fake.GroupMembers(groupID, "USER"); len(members) != 1 || members[0] != userID {
+							return fmt.Errorf("unexpected group members: %v", members)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// Member removed outside Terraform must be planned for re-adding
+				PreConfig: func() {
+					fake.RemoveGroupMembers(groupID, "USER", userID)
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestResourceIAMGroupMembership_authoritative(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_group_membership.test"
+	var groupID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// More members than IAM returns or accepts in one call
+				Config: fake.ProviderConfig() + testResourceIAMGroupMembershipMode(fake.RootOrgID, "authoritative", 2100),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "services.#", "2100"),
+					resource.TestCheckResourceAttr(resourceName, "devices.#", "1"),
+					func(s *terraform.State) error {
+						groupID = s.RootModule().Resources["hsdp_iam_group.test"].Primary.ID
+						if members := fake.GroupMembers(groupID, "SERVICE"); len(members) != 2100 {
+							return fmt.Errorf("expected 2100 services, got %d", len(members))
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// Members added outside Terraform are removed
+				PreConfig: func() {
+					fake.AddGroupMembers(groupID, "SERVICE", "unmanaged")
+				},
+				Config: fake.ProviderConfig() + testResourceIAMGroupMembershipMode(fake.RootOrgID, "authoritative", 2100),
+				Check: func(_ *terraform.State) error {
+					if members := fake.GroupMembers(groupID, "SERVICE"); tools.ContainsString(members, "unmanaged") {
+						return fmt.Errorf("unmanaged service was not removed")
+					}
+					return nil
+				},
+			},
+			{
+				// Additive mode leaves them alone
+				PreConfig: func() {
+					fake.AddGroupMembers(groupID, "SERVICE", "unmanaged")
+				},
+				Config: fake.ProviderConfig() + testResourceIAMGroupMembershipMode(fake.RootOrgID, "additive", 10),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "services.#", "10"),
+					func(_ *terraform.State) error {
+						members := fake.GroupMembers(groupID, "SERVICE")
+						if len(members) != 11 || !tools.ContainsString(members, "unmanaged") {
+							return fmt.Errorf("unexpected services: %d", len(members))
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testResourceIAMGroupMembershipMode(parentOrgID, mode string, services int) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_group" "test" {
+  name                  = "test-group"
+  managing_organization = "%s"
+  description           = "Group membership modes"
+
+  drift_detection = false
+}
+
+resource "hsdp_iam_group_membership" "test" {
+  iam_group_id = hsdp_iam_group.test.id
+  mode         = "%s"
+  services     = [for i in range(%d) : format("service-%%04d", i)]
+  devices      = ["device-1"]
+}
+`, parentOrgID, mode, services)
+}
+
 func testAccResourceIAMUser(parentOrgID, name, password string) string {
 	return fmt.Sprintf(`
 resource "hsdp_iam_user" "test" {
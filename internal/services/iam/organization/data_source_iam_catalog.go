@@ -0,0 +1,281 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// catalogPageSize is the number of entries requested per page
+const catalogPageSize = 100
+
+func computedString() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeString,
+		Computed: true,
+	}
+}
+
+func computedStringSet() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Computed: true,
+		Elem:     tools.StringSchema(),
+	}
+}
+
+// DataSourceIAMCatalog returns the propositions of an organization together
+// with their applications, services and clients
+func DataSourceIAMCatalog() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the propositions of an organization with their applications, services and clients.",
+		ReadContext: dataSourceIAMCatalogRead,
+		Schema: map[string]*schema.Schema{
+			"organization_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"propositions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":                  computedString(),
+						"name":                computedString(),
+						"description":         computedString(),
+						"global_reference_id": computedString(),
+						"applications": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"id":                  computedString(),
+									"name":                computedString(),
+									"description":         computedString(),
+									"global_reference_id": computedString(),
+									"services": {
+										Type:     schema.TypeList,
+										Computed: true,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"id":             computedString(),
+												"name":           computedString(),
+												"description":    computedString(),
+												"service_id":     computedString(),
+												"expires_on":     computedString(),
+												"scopes":         computedStringSet(),
+												"default_scopes": computedStringSet(),
+											},
+										},
+									},
+									"clients": {
+										Type:     schema.TypeList,
+										Computed: true,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"id":                  computedString(),
+												"name":                computedString(),
+												"description":         computedString(),
+												"client_id":           computedString(),
+												"type":                computedString(),
+												"global_reference_id": computedString(),
+												"redirection_uris":    computedStringSet(),
+												"scopes":              computedStringSet(),
+												"default_scopes":      computedStringSet(),
+												"disabled": {
+													Type:     schema.TypeBool,
+													Computed: true,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// organizationPropositions lists all propositions of orgID page by page
+func organizationPropositions(client *iam.Client, orgID string) ([]iam.Proposition, error) {
+	var propositions []iam.Proposition
+	for page := 1; ; page++ {
+		count := catalogPageSize
+		result, _, err := client.Propositions.GetPropositions(&iam.GetPropositionsOptions{
+			OrganizationID: &orgID,
+			Count:          &count,
+			Page:           &page,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return propositions, nil
+		}
+		propositions = append(propositions, *result...)
+		if len(*result) < catalogPageSize {
+			return propositions, nil
+		}
+	}
+}
+
+// withPage requests the given page of catalogPageSize entries from searches
+// whose options do not cover paging
+func withPage(page int) iam.OptionFunc {
+	return func(req *http.Request) error {
+		q := req.URL.Query()
+		q.Set("_count", strconv.Itoa(catalogPageSize))
+		q.Set("_page", strconv.Itoa(page))
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
+func catalogServices(client *iam.Client, applicationID string) ([]map[string]interface{}, error) {
+	var services []iam.Service
+	for page := 1; ; page++ {
+		result, _, err := client.Services.GetServices(&iam.GetServiceOptions{ApplicationID: &applicationID}, withPage(page))
+		if err != nil && !errors.Is(err, iam.ErrEmptyResults) {
+			return nil, fmt.Errorf("listing services of application %s: %w", applicationID, err)
+		}
+		if result == nil {
+			break
+		}
+		services = append(services, *result...)
+		if len(*result) < catalogPageSize {
+			break
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	list := make([]map[string]interface{}, 0, len(services))
+	for _, s := range services {
+		list = append(list, map[string]interface{}{
+			"id":             s.ID,
+			"name":           s.Name,
+			"description":    s.Description,
+			"service_id":     s.ServiceID,
+			"expires_on":     s.ExpiresOn,
+			"scopes":         s.Scopes,
+			"default_scopes": s.DefaultScopes,
+		})
+	}
+	return list, nil
+}
+
+func catalogClients(client *iam.Client, applicationID string) ([]map[string]interface{}, error) {
+	var clients []iam.ApplicationClient
+	for page := 1; ; page++ {
+		result, _, err := client.Clients.GetClients(&iam.GetClientsOptions{ApplicationID: &applicationID}, withPage(page))
+		if err != nil && !errors.Is(err, iam.ErrEmptyResults) {
+			return nil, fmt.Errorf("listing clients of application %s: %w", applicationID, err)
+		}
+		if result == nil {
+			break
+		}
+		clients = append(clients, *result...)
+		if len(*result) < catalogPageSize {
+			break
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Name < clients[j].Name })
+	list := make([]map[string]interface{}, 0, len(clients))
+	for _, cl := range clients {
+		list = append(list, map[string]interface{}{
+			"id":                  cl.ID,
+			"name":                cl.Name,
+			"description":         cl.Description,
+			"client_id":           cl.ClientID,
+			"type":                cl.Type,
+			"global_reference_id": cl.GlobalReferenceID,
+			"redirection_uris":    cl.RedirectionURIs,
+			"scopes":              cl.Scopes,
+			"default_scopes":      cl.DefaultScopes,
+			"disabled":            cl.Disabled,
+		})
+	}
+	return list, nil
+}
+
+func catalogApplications(client *iam.Client, propositionID string) ([]map[string]interface{}, error) {
+	var apps []*iam.Application
+	for page := 1; ; page++ {
+		result, _, err := client.Applications.GetApplications(&iam.GetApplicationsOptions{PropositionID: &propositionID}, withPage(page))
+		if err != nil && !errors.Is(err, iam.ErrEmptyResults) {
+			return nil, fmt.Errorf("listing applications of proposition %s: %w", propositionID, err)
+		}
+		apps = append(apps, result...)
+		if len(result) < catalogPageSize {
+			break
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+	list := make([]map[string]interface{}, 0, len(apps))
+	for _, app := range apps {
+		services, err := catalogServices(client, app.ID)
+		if err != nil {
+			return nil, err
+		}
+		clients, err := catalogClients(client, app.ID)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, map[string]interface{}{
+			"id":                  app.ID,
+			"name":                app.Name,
+			"description":         app.Description,
+			"global_reference_id": app.GlobalReferenceID,
+			"services":            services,
+			"clients":             clients,
+		})
+	}
+	return list, nil
+}
+
+func dataSourceIAMCatalogRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c := meta.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	orgID := d.Get("organization_id").(string)
+
+	propositions, err := organizationPropositions(client, orgID)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("listing propositions of organization %s: %w", orgID, err))
+	}
+	sort.Slice(propositions, func(i, j int) bool { return propositions[i].Name < propositions[j].Name })
+	list := make([]map[string]interface{}, 0, len(propositions))
+	for _, prop := range propositions {
+		applications, err := catalogApplications(client, prop.ID)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		list = append(list, map[string]interface{}{
+			"id":                  prop.ID,
+			"name":                prop.Name,
+			"description":         prop.Description,
+			"global_reference_id": prop.GlobalReferenceID,
+			"applications":        applications,
+		})
+	}
+	d.SetId(orgID)
+	if err := d.Set("propositions", list); err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
+}
@@ -0,0 +1,60 @@
+package organization
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogPaging(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client, err := iam.NewClient(nil, &iam.Config{
+		Region:         "us-east",
+		Environment:    "client-test",
+		OAuth2ClientID: fakehsdp.ClientID,
+		OAuth2Secret:   fakehsdp.ClientPassword,
+		IAMURL:         fake.URL,
+		IDMURL:         fake.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	propositionID := "4d2a7ef7-5ec3-4d43-a8b4-2b1b1c3a1e33"
+	var applicationID string
+	for i := 0; i <= catalogPageSize; i++ {
+		app, _, err := client.Applications.CreateApplication(iam.Application{
+			Name:              fmt.Sprintf("app%03d", i),
+			PropositionID:     propositionID,
+			GlobalReferenceID: fmt.Sprintf("app%03d", i),
+		})
+		require.NoError(t, err)
+		applicationID = app.ID
+	}
+	for i := 0; i <= catalogPageSize; i++ {
+		_, _, err := client.Clients.CreateClient(iam.ApplicationClient{
+			ClientID:          fmt.Sprintf("client%03d", i),
+			Name:              fmt.Sprintf("client%03d", i),
+			Type:              "Public",
+			Password:          "Init1al!pass",
+			ApplicationID:     applicationID,
+			GlobalReferenceID: fmt.Sprintf("client%03d", i),
+			RedirectionURIs:   []string{"https://example.com/callback"},
+			ResponseTypes:     []string{"code"},
+		})
+		require.NoError(t, err)
+	}
+
+	clients, err := catalogClients(client, applicationID)
+	require.NoError(t, err)
+	assert.Len(t, clients, catalogPageSize+1)
+
+	applications, err := catalogApplications(client, propositionID)
+	require.NoError(t, err)
+	require.Len(t, applications, catalogPageSize+1)
+	assert.Equal(t, "app000", applications[0]["name"])
+	assert.Len(t, applications[catalogPageSize]["clients"], catalogPageSize+1)
+}
@@ -0,0 +1,85 @@
+package organization_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestDataSourceIAMCatalog_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	dataSourceName := "data.hsdp_iam_catalog.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testDataSourceIAMCatalog(fake.RootOrgID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "propositions.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "propositions.0.name", "billing"),
+					resource.TestCheckResourceAttr(dataSourceName, "propositions.0.applications.#", "0"),
+					resource.TestCheckResourceAttr(dataSourceName, "propositions.1.applications.0.name", "portal"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "propositions.1.applications.0.services.0.service_id", "hsdp_iam_service.test", "service_id"),
+					resource.TestCheckResourceAttr(dataSourceName, "propositions.1.applications.0.clients.0.client_id", "portalweb"),
+				),
+			},
+		},
+	})
+}
+
+func testDataSourceIAMCatalog(orgID string) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_proposition" "billing" {
+  name            = "billing"
+  description     = "Billing"
+  organization_id = "%[1]s"
+}
+
+resource "hsdp_iam_proposition" "test" {
+  name            = "clinical"
+  description     = "Clinical"
+  organization_id = "%[1]s"
+}
+
+resource "hsdp_iam_application" "test" {
+  name           = "portal"
+  description    = "Portal"
+  proposition_id = hsdp_iam_proposition.test.id
+}
+
+resource "hsdp_iam_service" "test" {
+  name           = "backend"
+  description    = "Backend"
+  application_id = hsdp_iam_application.test.id
+  scopes         = ["openid"]
+  default_scopes = ["openid"]
+}
+
+resource "hsdp_iam_client" "test" {
+  name                = "portalweb"
+  type                = "Public"
+  client_id           = "portalweb"
+  password            = "Init1al!pass"
+  application_id      = hsdp_iam_application.test.id
+  global_reference_id = "portal-web"
+  description         = "Portal web"
+  redirection_uris    = ["https://portal.example.com/callback"]
+  response_types      = ["code"]
+  scopes              = ["openid"]
+  default_scopes      = ["openid"]
+}
+
+data "hsdp_iam_catalog" "test" {
+  organization_id = "%[1]s"
+
+  depends_on = [hsdp_iam_proposition.billing, hsdp_iam_service.test, hsdp_iam_client.test]
+}
+`, orgID)
+}
@@ -0,0 +1,231 @@
+package organization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// childrenPageSize is the number of sub organizations requested per SCIM page
+const childrenPageSize = 100
+
+// DataSourceIAMOrgTree returns all sub organizations of an organization
+func DataSourceIAMOrgTree() *schema.Resource {
+	return &schema.Resource{
+		Description: "Walks the sub organizations of an organization recursively.",
+		ReadContext: dataSourceIAMOrgTreeRead,
+		Schema: map[string]*schema.Schema{
+			"organization_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"depth": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     tools.StringSchema(),
+			},
+			"organizations": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"external_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"active": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"parent_org_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"depth": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// orgNode is an organization found while walking the tree
+type orgNode struct {
+	iam.Organization
+	Path  string
+	Depth int
+}
+
+// subOrganizations lists the direct children of parentID page by page. The
+// go-hsdp-api IAM client only returns the first match of a search, so the
+// SCIM endpoint is called directly with the token of the client.
+func subOrganizations(ctx context.Context, client *iam.Client, parentID string) ([]iam.Organization, error) {
+	var children []iam.Organization
+	for start := 1; ; {
+		var page struct {
+			TotalResults int                `json:"totalResults"`
+			Resources    []iam.Organization `json:"Resources"`
+		}
+		err := tools.TryHTTPCall(ctx, 5, func() (*http.Response, error) {
+			return scimGet(ctx, client, "authorize/scim/v2/Organizations", url.Values{
+				"filter":     {fmt.Sprintf("parent.value eq \"%s\"", parentID)},
+				"startIndex": {strconv.Itoa(start)},
+				"count":      {strconv.Itoa(childrenPageSize)},
+			}, &page)
+		})
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, page.Resources...)
+		start += len(page.Resources)
+		if len(page.Resources) == 0 || len(children) >= page.TotalResults {
+			return children, nil
+		}
+	}
+}
+
+func scimGet(ctx context.Context, client *iam.Client, path string, query url.Values, result interface{}) (*http.Response, error) {
+	token, err := client.Token()
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	u := *client.BaseIDMURL()
+	u.Path += path
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Api-Version", "2")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.HttpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusUnauthorized {
+			_ = client.TokenRefresh()
+		}
+		return resp, fmt.Errorf("GET %s: status=%d body=[%s]", path, resp.StatusCode, data)
+	}
+	return resp, json.Unmarshal(data, result)
+}
+
+// walkOrgTree visits the sub organizations of root depth first, in name order.
+// A maxDepth of zero means no limit.
+func walkOrgTree(ctx context.Context, client *iam.Client, root iam.Organization, maxDepth int) ([]orgNode, error) {
+	var nodes []orgNode
+	seen := map[string]bool{root.ID: true}
+	var walk func(parent iam.Organization, path string, depth int) error
+	walk = func(parent iam.Organization, path string, depth int) error {
+		if maxDepth > 0 && depth > maxDepth {
+			return nil
+		}
+		children, err := subOrganizations(ctx, client, parent.ID)
+		if err != nil {
+			return fmt.Errorf("listing sub organizations of %s: %w", parent.ID, err)
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+		for _, child := range children {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			node := orgNode{Organization: child, Path: path + "/" + child.Name, Depth: depth}
+			nodes = append(nodes, node)
+			if err := walk(child, node.Path, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, root.Name, 1); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func dataSourceIAMOrgTreeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c := meta.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	orgID := d.Get("organization_id").(string)
+
+	root, _, err := client.Organizations.GetOrganizationByID(orgID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	nodes, err := walkOrgTree(ctx, client, *root, d.Get("depth").(int))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ids := make([]string, 0, len(nodes))
+	organizations := make([]map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+		organizations = append(organizations, map[string]interface{}{
+			"id":            node.ID,
+			"name":          node.Name,
+			"type":          node.Type,
+			"external_id":   node.ExternalID,
+			"active":        node.Active,
+			"parent_org_id": node.Parent.Value,
+			"path":          node.Path,
+			"depth":         node.Depth,
+		})
+	}
+	d.SetId(orgID)
+	_ = d.Set("ids", ids)
+	_ = d.Set("organizations", organizations)
+
+	return diags
+}
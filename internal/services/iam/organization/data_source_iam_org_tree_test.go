@@ -0,0 +1,58 @@
+package organization_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestDataSourceIAMOrgTree_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	tenants := fake.AddOrganization("tenants", fake.RootOrgID)
+	for i := 0; i < 150; i++ {
+		fake.AddOrganization(fmt.Sprintf("tenant-%03d", i), tenants)
+	}
+	site := fake.AddOrganization("site", fake.AddOrganization("hospital", fake.RootOrgID))
+	fake.AddOrganization("ward", site)
+	dataSourceName := "data.hsdp_iam_org_tree.test"
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testDataSourceIAMOrgTree(fake.RootOrgID, 0),
+				Check: resource.ComposeTestCheckFunc(
+					// More children than fit on one page
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "155"),
+					resource.TestCheckResourceAttr(dataSourceName, "organizations.0.path", "ROOT/hospital"),
+					resource.TestCheckResourceAttr(dataSourceName, "organizations.2.path", "ROOT/hospital/site/ward"),
+					resource.TestCheckResourceAttr(dataSourceName, "organizations.2.depth", "3"),
+					resource.TestCheckResourceAttr(dataSourceName, "organizations.2.parent_org_id", site),
+					resource.TestCheckResourceAttr(dataSourceName, "organizations.3.active", "true"),
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testDataSourceIAMOrgTree(fake.RootOrgID, 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "ids.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "organizations.1.id", tenants),
+				),
+			},
+		},
+	})
+}
+
+func testDataSourceIAMOrgTree(orgID string, depth int) string {
+	return fmt.Sprintf(`
+data "hsdp_iam_org_tree" "test" {
+  organization_id = "%s"
+  depth           = %d
+}
+`, orgID, depth)
+}
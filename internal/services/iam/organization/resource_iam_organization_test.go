@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"testing"
 
-        "github.com/hashicorp/terraform-plugin-testing/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMOrganization_basic(t *testing.T) {
@@ -33,6 +35,49 @@ func TestAccResourceIAMOrganization_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMOrganization_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_org.test"
+	config := fake.ProviderConfig() + testAccResourceIAMOrganization(fake.RootOrgID, "fake")
+	var orgID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", "ACCTest-fake"),
+					resource.TestCheckResourceAttr(resourceName, "parent_org_id", fake.RootOrgID),
+					func(s *terraform.State) error {
+						orgID = s.RootModule().Resources[resourceName].Primary.ID
+						return nil
+					},
+				),
+			},
+			{
+				Config:                  config,
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"wait_for_delete"},
+			},
+			{
+				// Organization deleted outside Terraform must be planned for re-creation
+				PreConfig: func() {
+					fake.DeleteOrganization(orgID)
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
 func testAccResourceIAMOrganization(parentOrgID, name string) string {
 	return fmt.Sprintf(`
 resource "hsdp_iam_org" "test" {
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMProposition_basic(t *testing.T) {
@@ -36,6 +37,35 @@ func TestAccResourceIAMProposition_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMProposition_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_proposition.fake"
+	config := fake.ProviderConfig() + testAccResourceIAMProposition(fake.RootOrgID, "fake")
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", "ACC-FAKE"),
+					resource.TestCheckResourceAttrPair(resourceName, "organization_id", "hsdp_iam_org.fake", "id"),
+				),
+			},
+			{
+				Config:                  config,
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"wait_for_delete"},
+			},
+		},
+	})
+}
+
 func testAccResourceIAMProposition(parentOrgID, name string) string {
 	// We create a completely separate ORG as that is currently
 	// the only way we can clean up Propositions and Applications
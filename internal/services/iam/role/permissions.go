@@ -0,0 +1,72 @@
+package role
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// ticketOnlyPermissions can only be assigned by HSDP support through a ticket
+var ticketOnlyPermissions = []string{"CLIENT.SCOPES"}
+
+var (
+	catalogMu sync.Mutex
+	catalogs  = make(map[*iam.Client]map[string]bool)
+)
+
+// permissionCatalog returns the names of all permissions known to IAM. The
+// catalog is the one exposed by the hsdp_iam_permissions data source and is
+// fetched once per client.
+func permissionCatalog(client *iam.Client) (map[string]bool, error) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	if catalog, ok := catalogs[client]; ok {
+		return catalog, nil
+	}
+	permissions, _, err := client.Permissions.GetPermissions(nil)
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(map[string]bool)
+	for _, p := range *permissions {
+		catalog[p.Name] = true
+	}
+	catalogs[client] = catalog
+	return catalog, nil
+}
+
+// checkPermissionChanges validates permission additions against the catalog,
+// reporting every offending permission at once so a role is never left half
+// applied. Under ticket protection ticket only permissions are rejected too.
+func checkPermissionChanges(catalog map[string]bool, toAdd []string, ticketProtection bool) error {
+	var unknown, ticketOnly []string
+	for _, p := range toAdd {
+		switch {
+		case ticketProtection && tools.ContainsString(ticketOnlyPermissions, p):
+			ticketOnly = append(ticketOnly, p)
+		case !catalog[p]:
+			unknown = append(unknown, p)
+		}
+	}
+	var problems []string
+	if len(unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown permissions: %s", joinSorted(unknown)))
+	}
+	if len(ticketOnly) > 0 {
+		problems = append(problems, fmt.Sprintf("permissions which can only be assigned through an HSDP support ticket: %s", joinSorted(ticketOnly)))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func joinSorted(list []string) string {
+	sorted := append([]string(nil), list...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
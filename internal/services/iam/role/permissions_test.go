@@ -0,0 +1,22 @@
+package role
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPermissionChanges(t *testing.T) {
+	catalog := map[string]bool{"DATAITEM.READ": true, "CLIENT.SCOPES": true}
+
+	assert.NoError(t, checkPermissionChanges(catalog, []string{"DATAITEM.READ"}, true))
+
+	err := checkPermissionChanges(catalog, []string{"DATAITEM.RAED", "CONTRACT.READ", "CLIENT.SCOPES"}, true)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "unknown permissions: CONTRACT.READ, DATAITEM.RAED")
+		assert.Contains(t, err.Error(), "HSDP support ticket: CLIENT.SCOPES")
+	}
+
+	// Without protection ticket only permissions are validated like any other
+	assert.NoError(t, checkPermissionChanges(catalog, []string{"CLIENT.SCOPES"}, false))
+}
@@ -28,6 +28,7 @@ func ResourceIAMRole() *schema.Resource {
 		ReadContext:   resourceIAMRoleRead,
 		UpdateContext: resourceIAMRoleUpdate,
 		DeleteContext: resourceIAMRoleDelete,
+		CustomizeDiff: resourceIAMRoleCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -52,9 +53,10 @@ func ResourceIAMRole() *schema.Resource {
 			"permissions": {
 				Type:        schema.TypeSet,
 				MaxItems:    100,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				Elem:        &schema.Schema{Type: schema.TypeString},
-				Description: "List of permissions IDs assigned to this role.",
+				Description: "List of permissions IDs assigned to this role. Leave out when granting permissions with hsdp_iam_role_permission.",
 			},
 			"ticket_protection": {
 				Type:        schema.TypeBool,
@@ -66,6 +68,32 @@ func ResourceIAMRole() *schema.Resource {
 	}
 }
 
+func resourceIAMRoleCustomizeDiff(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.HasChange("permissions") || !d.NewValueKnown("permissions") {
+		return nil
+	}
+	c := m.(*config.Config)
+
+	o, n := d.GetChange("permissions")
+	oldList := tools.ExpandStringList(o.(*schema.Set).List())
+	newList := tools.ExpandStringList(n.(*schema.Set).List())
+	toAdd := tools.Difference(newList, oldList)
+	if len(toAdd) == 0 {
+		return nil
+	}
+	client, err := c.IAMClient()
+	if err != nil {
+		return err
+	}
+	catalog, err := permissionCatalog(client)
+	if err != nil {
+		// Not every identity may read the catalog, IAM validates at apply time instead
+		_, _ = c.Debug("skipping permission validation: %v\n", err)
+		return nil
+	}
+	return checkPermissionChanges(catalog, toAdd, d.Get("ticket_protection").(bool))
+}
+
 func resourceIAMRoleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
@@ -189,6 +217,18 @@ func resourceIAMRoleRead(ctx context.Context, d *schema.ResourceData, m interfac
 		}
 		return diag.FromErr(err)
 	}
+	if d.Get("ticket_protection").(bool) {
+		// Ticket only permissions added outside Terraform are not managed
+		known := tools.ExpandStringList(d.Get("permissions").(*schema.Set).List())
+		var managed []string
+		for _, p := range *permissions {
+			if tools.ContainsString(ticketOnlyPermissions, p) && !tools.ContainsString(known, p) {
+				continue
+			}
+			managed = append(managed, p)
+		}
+		permissions = &managed
+	}
 	_ = d.Set("permissions", permissions)
 	return diags
 }
@@ -220,6 +260,9 @@ func resourceIAMRoleUpdate(ctx context.Context, d *schema.ResourceData, m interf
 		toAdd := tools.Difference(newList, oldList)
 		toRemove := tools.Difference(oldList, newList)
 
+		if d.Get("ticket_protection").(bool) {
+			toRemove = tools.Difference(toRemove, ticketOnlyPermissions)
+		}
 		res := addAndRemovePermissions(ctx, *role, toAdd, toRemove, client)
 		diags = append(diags, res...)
 	}
@@ -0,0 +1,166 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+// ResourceIAMRolePermission grants a single permission to a role, leaving
+// the other permissions of the role alone
+func ResourceIAMRolePermission() *schema.Resource {
+	return &schema.Resource{
+		Description: "Grants a single permission to a role. Other permissions of the role are not managed.",
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceIAMRolePermissionImport,
+		},
+		CreateContext: resourceIAMRolePermissionCreate,
+		ReadContext:   resourceIAMRolePermissionRead,
+		DeleteContext: resourceIAMRolePermissionDelete,
+		CustomizeDiff: resourceIAMRolePermissionCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"role_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "The ID of the role.",
+			},
+			"permission": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "The permission to grant.",
+			},
+		},
+	}
+}
+
+func resourceIAMRolePermissionImport(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	roleID, permission, ok := strings.Cut(d.Id(), "/")
+	if !ok || roleID == "" || permission == "" {
+		return nil, fmt.Errorf("expecting role_id/permission as import string")
+	}
+	_ = d.Set("role_id", roleID)
+	_ = d.Set("permission", permission)
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceIAMRolePermissionCustomizeDiff(_ context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.HasChange("permission") || !d.NewValueKnown("permission") {
+		return nil
+	}
+	c := m.(*config.Config)
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return err
+	}
+	catalog, err := permissionCatalog(client)
+	if err != nil {
+		_, _ = c.Debug("skipping permission validation: %v\n", err)
+		return nil
+	}
+	return checkPermissionChanges(catalog, []string{d.Get("permission").(string)}, false)
+}
+
+func resourceIAMRolePermissionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	roleID := d.Get("role_id").(string)
+	permission := d.Get("permission").(string)
+
+	role, _, err := client.Roles.GetRoleByID(roleID)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error retrieving role '%s': %w", roleID, err))
+	}
+	err = tools.TryHTTPCall(ctx, 8, func() (*http.Response, error) {
+		result, resp, err := client.Roles.AddRolePermission(*role, permission)
+		if err != nil {
+			_ = client.TokenRefresh()
+		}
+		if resp == nil {
+			return nil, err
+		}
+		if resp.StatusCode() == http.StatusNotFound {
+			return resp.Response, backoff.Permanent(fmt.Errorf("permission '%s' is invalid: %v", permission, result))
+		}
+		return resp.Response, err
+	})
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error adding permission '%s': %w", permission, err))
+	}
+	d.SetId(role.ID + "/" + permission)
+	return resourceIAMRolePermissionRead(ctx, d, m)
+}
+
+func resourceIAMRolePermissionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var role iam.Role
+	role.ID = d.Get("role_id").(string)
+
+	var permissions *[]string
+	var resp *iam.Response
+	err = tools.TryHTTPCall(ctx, 8, func() (*http.Response, error) {
+		permissions, resp, err = client.Roles.GetRolePermissions(role)
+		if resp == nil {
+			return nil, err
+		}
+		return resp.Response, err
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode() == http.StatusNotFound {
+			d.SetId("")
+			return diags
+		}
+		return diag.FromErr(err)
+	}
+	if permissions == nil || !tools.ContainsString(*permissions, d.Get("permission").(string)) {
+		// Removed outside of Terraform
+		d.SetId("")
+	}
+	return diags
+}
+
+func resourceIAMRolePermissionDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+
+	var diags diag.Diagnostics
+
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var role iam.Role
+	role.ID = d.Get("role_id").(string)
+	permission := d.Get("permission").(string)
+
+	_, resp, err := client.Roles.RemoveRolePermission(role, permission)
+	if err != nil && !(resp != nil && resp.StatusCode() == http.StatusNotFound) {
+		return diag.FromErr(fmt.Errorf("error removing permission '%s': %w", permission, err))
+	}
+	d.SetId("")
+	return diags
+}
@@ -2,12 +2,15 @@ package role_test
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMRole_basic(t *testing.T) {
@@ -34,6 +37,107 @@ func TestAccResourceIAMRole_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMRole_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	for _, permission := range []string{"DATAITEM.CREATEONBEHALF", "DATAITEM.READ", "DATAITEM.DELETEONBEHALF", "DATAITEM.DELETE", "CONTRACT.CREATE", "DATAITEM.READONBEHALF", "CONTRACT.READ", "DATAITEM.CREATE"} {
+		fake.AddPermission(permission)
+	}
+	resourceName := "hsdp_iam_role.test"
+	config := fake.ProviderConfig() + testAccResourceIAMRole(fake.RootOrgID, "fake")
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "managing_organization", fake.RootOrgID),
+					resource.TestCheckResourceAttr(resourceName, "permissions.#", "8"),
+				),
+			},
+			{
+				Config:                  config,
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"ticket_protection"},
+			},
+		},
+	})
+}
+
+func TestResourceIAMRolePermission_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	for _, permission := range []string{"DATAITEM.READ", "CONTRACT.READ"} {
+		fake.AddPermission(permission)
+	}
+	resourceName := "hsdp_iam_role_permission.test"
+	var roleID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// Typos are reported at plan time, before anything is applied
+				Config:      fake.ProviderConfig() + testResourceIAMRolePermission(fake.RootOrgID, "DATAITEM.RAED"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("unknown permissions: DATAITEM.RAED"),
+			},
+			{
+				Config: fake.ProviderConfig() + testResourceIAMRolePermission(fake.RootOrgID, "CONTRACT.READ"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "permission", "CONTRACT.READ"),
+					func(s *terraform.State) error {
+						roleID = s.RootModule().Resources["hsdp_iam_role.test"].Primary.ID
+						if permissions := fake.RolePermissions(roleID); strings.Join(permissions, ",") != "CONTRACT.READ,DATAITEM.READ" {
+							return fmt.Errorf("unexpected permissions: %v", permissions)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// The role does not report the additive grants as drift
+				Config:   fake.ProviderConfig() + testResourceIAMRolePermission(fake.RootOrgID, "CONTRACT.READ"),
+				PlanOnly: true,
+			},
+			{
+				ResourceName: resourceName,
+				ImportState:  true,
+				ImportStateIdFunc: func(_ *terraform.State) (string, error) {
+					return roleID + "/CONTRACT.READ", nil
+				},
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testResourceIAMRolePermission(parentOrgID, permission string) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_role" "test" {
+  name                  = "TESTROLE-PERMISSION"
+  managing_organization = "%s"
+}
+
+resource "hsdp_iam_role_permission" "dataitem" {
+  role_id    = hsdp_iam_role.test.id
+  permission = "DATAITEM.READ"
+}
+
+resource "hsdp_iam_role_permission" "test" {
+  role_id    = hsdp_iam_role.test.id
+  permission = "%s"
+}
+`, parentOrgID, permission)
+}
+
 func testAccResourceIAMRole(parentOrgID, name string) string {
 	roleName := fmt.Sprintf("TESTROLE-%s", strings.ToUpper(name))
 	return fmt.Sprintf(`
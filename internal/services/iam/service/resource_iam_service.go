@@ -115,6 +115,21 @@ func ResourceIAMService() *schema.Resource {
 					"Mutually exclusive with `self_managed_certificate`",
 				ConflictsWith: []string{"self_managed_certificate"},
 			},
+			"private_key_wo": {
+				Type:          schema.TypeString,
+				Sensitive:     true,
+				Optional:      true,
+				WriteOnly:     true,
+				ConflictsWith: []string{"self_managed_private_key", "self_managed_certificate", "self_managed_certificate_nonsensitive"},
+				Description: "Write-only RSA private key in PEM format. When provided, overrides the generated certificate / private key combination of the IAM service. " +
+					"The key is never stored in state and `private_key` is left empty. Requires Terraform 1.11 or newer.",
+			},
+			"private_key_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				RequiredWith: []string{"private_key_wo"},
+				Description:  "Version of `private_key_wo`. As write-only values are not stored, change this value to install a new private key.",
+			},
 			"private_key": {
 				Type:        schema.TypeString,
 				Sensitive:   true,
@@ -181,7 +196,12 @@ func resourceIAMServiceCreate(ctx context.Context, d *schema.ResourceData, m int
 		selfCertificate = selfCertificateNS
 	}
 
-	if selfPrivateKey == "" && selfExpiresOn != "" {
+	privateKeyWO, diags := tools.WriteOnlyString(d, "private_key_wo")
+	if diags.HasError() {
+		return diags
+	}
+
+	if selfPrivateKey == "" && privateKeyWO == "" && selfExpiresOn != "" {
 		return diag.FromErr(fmt.Errorf("you cannot set 'self_managed_expires_on' value without also specifying the 'self_managed_private_key'"))
 	}
 	if selfCertificate != "" && selfExpiresOn != "" {
@@ -215,6 +235,15 @@ func resourceIAMServiceCreate(ctx context.Context, d *schema.ResourceData, m int
 			return diags
 		}
 	}
+	// Set write-only private key, which must not end up in state
+	if privateKeyWO != "" {
+		_, diags = updateServicePrivateKey(client, *createdService, privateKeyWO, selfExpiresOn)
+		if len(diags) > 0 {
+			_, _, _ = client.Services.DeleteService(*createdService) // Cleanup
+			return diags
+		}
+		_ = d.Set("private_key", "")
+	}
 	// Set certificate if set from publicKey
 	if selfCertificate != "" {
 		diags = setSelfManagedCertificate(client, *createdService, selfCertificate)
@@ -332,6 +361,18 @@ func resourceIAMServiceUpdate(ctx context.Context, d *schema.ResourceData, m int
 			_, _, _ = client.Services.AddScopes(s, []string{}, toAdd)
 		}
 	}
+	if d.HasChange("private_key_wo_version") {
+		privateKeyWO, diags := tools.WriteOnlyString(d, "private_key_wo")
+		if diags.HasError() {
+			return diags
+		}
+		if privateKeyWO != "" {
+			_, diags = updateServicePrivateKey(client, s, privateKeyWO, d.Get("self_managed_expires_on").(string))
+			if len(diags) > 0 {
+				return diags
+			}
+		}
+	}
 	if d.HasChange("self_managed_certificate") ||
 		d.HasChange("self_managed_certificate_nonsensitive") {
 		_, newCertificate := d.GetChange("self_managed_certificate")
@@ -376,41 +417,50 @@ func resourceIAMServiceDelete(_ context.Context, d *schema.ResourceData, m inter
 }
 
 func setSelfManagedPrivateKey(client *iam.Client, service iam.Service, d *schema.ResourceData) diag.Diagnostics {
-	var diags diag.Diagnostics
-
 	selfPrivateKey := d.Get("self_managed_private_key").(string)
 	selfExpiresOn := d.Get("self_managed_expires_on").(string)
+
+	fixedPEM, diags := updateServicePrivateKey(client, service, selfPrivateKey, selfExpiresOn)
+	if len(diags) > 0 {
+		return diags
+	}
+	if fixedPEM != "" {
+		_ = d.Set("private_key", fixedPEM)
+	}
+	return diags
+}
+
+// updateServicePrivateKey replaces the credentials of service with a certificate
+// for privateKey and returns the normalized PEM of the key
+func updateServicePrivateKey(client *iam.Client, service iam.Service, privateKey, selfExpiresOn string) (string, diag.Diagnostics) {
 	expiresOn := time.Now().Add(5 * 86400 * 365 * time.Second)
 	if selfExpiresOn != "" {
 		parsedExpiresOn, err := time.Parse(time.RFC3339, selfExpiresOn)
 		if err != nil {
-			return diag.FromErr(fmt.Errorf("parsing expires_on: %w", err))
+			return "", diag.FromErr(fmt.Errorf("parsing expires_on: %w", err))
 		}
 		expiresOn = parsedExpiresOn
 	}
-	fixedPEM := iam.FixPEM(selfPrivateKey)
+	fixedPEM := iam.FixPEM(privateKey)
 	block, _ := pem.Decode([]byte(fixedPEM))
 	if block == nil {
-		block, _ = pem.Decode([]byte(selfPrivateKey)) // Try unmodified decode
+		block, _ = pem.Decode([]byte(privateKey)) // Try unmodified decode
 		if block == nil {
-			return diag.FromErr(fmt.Errorf("error decoding 'self_managed_private_key'"))
+			return "", diag.FromErr(fmt.Errorf("error decoding private key"))
 		}
 	}
-	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("parsing private key: %w", err))
+		return "", diag.FromErr(fmt.Errorf("parsing private key: %w", err))
 	}
-	_, _, err = client.Services.UpdateServiceCertificate(service, privateKey, func(cert *x509.Certificate) error {
+	_, _, err = client.Services.UpdateServiceCertificate(service, rsaKey, func(cert *x509.Certificate) error {
 		cert.NotAfter = expiresOn
 		return nil
 	})
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("setting private key: %w", err))
+		return "", diag.FromErr(fmt.Errorf("setting private key: %w", err))
 	}
-	if fixedPEM != "" {
-		_ = d.Set("private_key", fixedPEM)
-	}
-	return diags
+	return fixedPEM, nil
 }
 
 func setSelfManagedCertificate(client *iam.Client, service iam.Service, selfCertificate string) diag.Diagnostics {
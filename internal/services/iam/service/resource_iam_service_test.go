@@ -1,13 +1,19 @@
 package service_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMService_basic(t *testing.T) {
@@ -96,3 +102,97 @@ resource "hsdp_iam_service" "%s" {
 		name,
 		name)
 }
+
+// installedCertificate returns the certificate of the service after verifying
+// it belongs to privateKey
+func installedCertificate(fake *fakehsdp.Server, serviceID, privateKey string) (*x509.Certificate, error) {
+	certBlock, _ := pem.Decode([]byte(fake.ServiceCertificate(serviceID)))
+	keyBlock, _ := pem.Decode([]byte(privateKey))
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("missing certificate or private key")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !key.PublicKey.Equal(cert.PublicKey.(*rsa.PublicKey)) {
+		return nil, fmt.Errorf("installed certificate does not match the private key")
+	}
+	return cert, nil
+}
+
+func TestResourceIAMService_privateKeyWO(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_service.test"
+	firstKey, secondKey := testPrivateKey(t), testPrivateKey(t)
+	var serviceID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceIAMServicePrivateKeyWO(firstKey, 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "private_key_wo"),
+					resource.TestCheckResourceAttr(resourceName, "private_key", ""),
+					resource.TestCheckResourceAttr(resourceName, "private_key_wo_version", "1"),
+					func(s *terraform.State) error {
+						serviceID = s.RootModule().Resources[resourceName].Primary.ID
+						_, err := installedCertificate(fake, serviceID, firstKey)
+						return err
+					},
+				),
+			},
+			{
+				// Bumping the version installs the new key on the same service
+				Config: fake.ProviderConfig() + testResourceIAMServicePrivateKeyWO(secondKey, 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "private_key_wo"),
+					resource.TestCheckResourceAttr(resourceName, "private_key", ""),
+					resource.TestCheckResourceAttr(resourceName, "private_key_wo_version", "2"),
+					func(s *terraform.State) error {
+						if id := s.RootModule().Resources[resourceName].Primary.ID; id != serviceID {
+							return fmt.Errorf("expected the service to be kept, got %s instead of %s", id, serviceID)
+						}
+						_, err := installedCertificate(fake, serviceID, secondKey)
+						return err
+					},
+				),
+			},
+		},
+	})
+}
+
+func testPrivateKey(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+}
+
+func testResourceIAMServicePrivateKeyWO(privateKey string, version int) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_service" "test" {
+  name           = "write-only"
+  description    = "Write-only private key"
+  application_id = "9a4b0e3e-8c36-4d0e-9a47-3a0f6c0f3a41"
+  scopes         = ["openid"]
+  default_scopes = ["openid"]
+
+  private_key_wo         = <<EOT
+%sEOT
+  private_key_wo_version = %d
+}
+`, privateKey, version)
+}
@@ -1,13 +1,18 @@
 package user
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/philips-software/terraform-provider-hsdp/internal/config"
 	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
@@ -51,10 +56,25 @@ func ResourceIAMUser() *schema.Resource {
 				Description:      "The email address of the user.",
 			},
 			"password": {
-				Type:        schema.TypeString,
-				Sensitive:   true,
-				Optional:    true,
-				Description: "When specified this will skip the email activation flow and immediately activate the IAM account. Very Important: you are responsible for sharing this password with the new IAM user through some channel of communication. No email will be triggered by the system. If unsure, do not set a password so the normal email activation flow is followed. Finally, any password value changes after user creation will have no effect on the users' actual password.",
+				Type:          schema.TypeString,
+				Sensitive:     true,
+				Optional:      true,
+				ConflictsWith: []string{"password_wo"},
+				Description:   "When specified this will skip the email activation flow and immediately activate the IAM account. Very Important: you are responsible for sharing this password with the new IAM user through some channel of communication. No email will be triggered by the system. If unsure, do not set a password so the normal email activation flow is followed. Finally, any password value changes after user creation will have no effect on the users' actual password.",
+			},
+			"password_wo": {
+				Type:          schema.TypeString,
+				Sensitive:     true,
+				Optional:      true,
+				WriteOnly:     true,
+				ConflictsWith: []string{"password"},
+				Description:   "Write-only variant of `password` which is never stored in state. Requires Terraform 1.11 or newer.",
+			},
+			"password_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				RequiredWith: []string{"password_wo"},
+				Description:  "Version of `password_wo`. As write-only values are not stored, change this value to signal a new password.",
 			},
 			"first_name": {
 				Type:        schema.TypeString,
@@ -89,6 +109,53 @@ func ResourceIAMUser() *schema.Resource {
 				DiffSuppressFunc: tools.SuppressDefaultCommunicationChannel,
 				Description:      "Preferred communication channel. Email and SMS are supported channels. Email is the default channel if e-mail address is provided. Values supported: [ email | sms ].",
 			},
+			"disabled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Disables the account. The user can no longer log in, but is kept with its audit trail.",
+			},
+			"disable_on_destroy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Disable the account instead of deleting it when the resource is destroyed.",
+			},
+			"resend_activation_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value resends the activation email.",
+			},
+			"mfa_reset_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value resets the MFA enrollment of the user, who has to enroll again.",
+			},
+			"force_password_change_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value makes the user change their password at the next login.",
+			},
+			"unlock_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value unlocks an account which was locked after failed login attempts.",
+			},
+			"locked": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the account is locked after failed login attempts.",
+			},
+			"must_change_password": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the user has to change their password at the next login.",
+			},
+			"mfa_status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The MFA status of the user.",
+			},
 			"access_status": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -110,7 +177,10 @@ func resourceIAMUserCreate(ctx context.Context, d *schema.ResourceData, m interf
 	email := d.Get("username").(string) // Deprecated
 	mobile := d.Get("mobile").(string)
 	login := d.Get("login").(string)
-	password := d.Get("password").(string)
+	password, diags := tools.SecretString(d, "password", "password_wo")
+	if diags.HasError() {
+		return diags
+	}
 	reversedPassword := tools.ReverseString(password)
 	if login == "" {
 		login = email
@@ -176,6 +246,13 @@ func resourceIAMUserCreate(ctx context.Context, d *schema.ResourceData, m interf
 		}
 	}
 	d.SetId(user.ID)
+	if d.Get("disabled").(bool) {
+		if err := updateProfile(client, user.ID, func(profile *iam.Profile) {
+			setDisabled(d, profile)
+		}); err != nil {
+			return diag.FromErr(fmt.Errorf("resourceIAMUserCreate: %w", err))
+		}
+	}
 	return resourceIAMUserRead(ctx, d, m)
 }
 
@@ -224,6 +301,10 @@ func resourceIAMUserRead(_ context.Context, d *schema.ResourceData, m interface{
 		_ = d.Set("organization_id", user.ManagingOrganization)
 		_ = d.Set("preferred_communication_channel", user.PreferredCommunicationChannel)
 		_ = d.Set("preferred_language", user.PreferredLanguage)
+		_ = d.Set("disabled", user.AccountStatus.Disabled)
+		_ = d.Set("locked", user.AccountStatus.AccountLockedUntil.After(time.Now()))
+		_ = d.Set("must_change_password", user.AccountStatus.MustChangePassword)
+		_ = d.Set("mfa_status", user.AccountStatus.MFAStatus)
 	}
 	return diags
 }
@@ -248,27 +329,42 @@ func resourceIAMUserUpdate(ctx context.Context, d *schema.ResourceData, m interf
 		}
 	}
 	if d.HasChange("last_name") || d.HasChange("first_name") || d.HasChange("email") ||
-		d.HasChange("mobile") || d.HasChange("preferred_language") || d.HasChange("preferred_communication_channel") {
-		profile, _, err := client.Users.LegacyGetUserByUUID(d.Id())
+		d.HasChange("mobile") || d.HasChange("preferred_language") || d.HasChange("preferred_communication_channel") ||
+		d.HasChange("disabled") {
+		err := updateProfile(client, d.Id(), func(profile *iam.Profile) {
+			profile.FamilyName = d.Get("last_name").(string)
+			profile.GivenName = d.Get("first_name").(string)
+			profile.PreferredLanguage = d.Get("preferred_language").(string)
+			profile.PreferredCommunicationChannel = d.Get("preferred_communication_channel").(string)
+			profile.Contact.EmailAddress = d.Get("email").(string)
+			profile.Contact.MobilePhone = d.Get("mobile").(string)
+			setDisabled(d, profile)
+		})
 		if err != nil {
-			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate LegacyGetUserByUUID: %w", err))
+			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate %w", err))
 		}
-		profile.FamilyName = d.Get("last_name").(string)
-		profile.GivenName = d.Get("first_name").(string)
-		profile.PreferredLanguage = d.Get("preferred_language").(string)
-		profile.PreferredCommunicationChannel = d.Get("preferred_communication_channel").(string)
-		profile.Contact.EmailAddress = d.Get("email").(string)
-		if profile.MiddleName == "" {
-			profile.MiddleName = " "
+	}
+	if d.HasChange("resend_activation_trigger") && d.Get("resend_activation_trigger").(string) != "" {
+		if _, _, err := client.Users.ResendActivation(d.Get("login").(string)); err != nil {
+			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate ResendActivation: %w", err))
 		}
-		profile.Contact.MobilePhone = d.Get("mobile").(string)
-		profile.ID = d.Id()
-		_, _, err = client.Users.LegacyUpdateUser(*profile)
-		if err != nil {
-			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate LegacyUpdateUser: %w", err))
+	}
+	if d.HasChange("mfa_reset_trigger") && d.Get("mfa_reset_trigger").(string) != "" {
+		if err := resetMFA(client, d.Id()); err != nil {
+			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate %w", err))
+		}
+	}
+	if d.HasChange("force_password_change_trigger") && d.Get("force_password_change_trigger").(string) != "" {
+		if err := forcePasswordChange(ctx, client, d.Id()); err != nil {
+			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate %w", err))
 		}
 	}
-	if d.HasChange("password") {
+	if d.HasChange("unlock_trigger") && d.Get("unlock_trigger").(string) != "" {
+		if ok, resp, err := client.Users.Unlock(d.Id()); !ok {
+			return diag.FromErr(fmt.Errorf("resourceIAMUserUpdate %w", actionError("Unlock", resp, err)))
+		}
+	}
+	if d.HasChange("password") || d.HasChange("password_wo_version") {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Warning,
 			Summary:  "password change not propagated",
@@ -301,6 +397,16 @@ func resourceIAMUserDelete(_ context.Context, d *schema.ResourceData, m interfac
 	if user == nil {
 		return diags
 	}
+	if d.Get("disable_on_destroy").(bool) {
+		disabled := true
+		if err := updateProfile(client, user.ID, func(profile *iam.Profile) {
+			profile.Disabled = &disabled
+		}); err != nil {
+			return diag.FromErr(fmt.Errorf("disabling user '%s': %w", user.ID, err))
+		}
+		d.SetId("")
+		return diags
+	}
 	var person iam.Person
 	person.ID = user.ID
 	_, resp, err := client.Users.DeleteUser(person)
@@ -320,3 +426,125 @@ func resourceIAMUserDelete(_ context.Context, d *schema.ResourceData, m interfac
 	d.SetId("")
 	return diags
 }
+
+// updateProfile changes the legacy profile of a user with modify
+func updateProfile(client *iam.Client, id string, modify func(profile *iam.Profile)) error {
+	profile, _, err := client.Users.LegacyGetUserByUUID(id)
+	if err != nil {
+		return fmt.Errorf("LegacyGetUserByUUID: %w", err)
+	}
+	modify(profile)
+	if profile.MiddleName == "" {
+		profile.MiddleName = " "
+	}
+	profile.ID = id
+	if _, _, err = client.Users.LegacyUpdateUser(*profile); err != nil {
+		return fmt.Errorf("LegacyUpdateUser: %w", err)
+	}
+	return nil
+}
+
+// forcePasswordChange makes the user change their password at the next login.
+// The IAM client clears mustChangePassword from profile updates, so the legacy
+// profile is sent to IDM directly.
+func forcePasswordChange(ctx context.Context, client *iam.Client, id string) error {
+	profile, _, err := client.Users.LegacyGetUserByUUID(id)
+	if err != nil {
+		return fmt.Errorf("LegacyGetUserByUUID: %w", err)
+	}
+	profile.ID = id
+	profile.PruneBlankAddresses()
+	profile.VerifiedMobilePhoneStatus = ""
+	profile.EmailVerifiedStatus = ""
+	profile.MustChangePassword = "true"
+	if profile.MiddleName == "" {
+		profile.MiddleName = " "
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return tools.TryHTTPCall(ctx, 5, func() (*http.Response, error) {
+		return legacyPut(ctx, client, "security/users/"+id, body)
+	})
+}
+
+// legacyPut sends body to a legacy IDM API, which reports errors in the
+// responseCode of the body
+func legacyPut(ctx context.Context, client *iam.Client, path string, body []byte) (*http.Response, error) {
+	token, err := client.Token()
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	u := *client.BaseIDMURL()
+	u.Path += path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Api-Version", "2")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.HttpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusUnauthorized {
+			_ = client.TokenRefresh()
+		}
+		return resp, fmt.Errorf("PUT %s: status=%d body=[%s]", path, resp.StatusCode, data)
+	}
+	var result struct {
+		ResponseCode    string `json:"responseCode"`
+		ResponseMessage string `json:"responseMessage"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return resp, backoff.Permanent(fmt.Errorf("PUT %s: %w", path, err))
+	}
+	if result.ResponseCode != "200" {
+		return resp, backoff.Permanent(fmt.Errorf("PUT %s: responseCode=%s: %s", path, result.ResponseCode, result.ResponseMessage))
+	}
+	return resp, nil
+}
+
+// setDisabled copies the managed account state to profile
+func setDisabled(d *schema.ResourceData, profile *iam.Profile) {
+	disabled := d.Get("disabled").(bool)
+	profile.Disabled = &disabled
+}
+
+// resetMFA removes the MFA enrollment of a user. When MFA was active it is
+// activated again, so the user has to enroll a new device at the next login.
+func resetMFA(client *iam.Client, id string) error {
+	user, _, err := client.Users.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if ok, resp, err := client.Users.SetMFA(id, false); !ok {
+		return actionError("SetMFA", resp, err)
+	}
+	if user.AccountStatus.MFAStatus != "ACTIVE" {
+		return nil
+	}
+	if ok, resp, err := client.Users.SetMFA(id, true); !ok {
+		return actionError("SetMFA", resp, err)
+	}
+	return nil
+}
+
+// actionError explains why a user action which reports success as a boolean failed
+func actionError(action string, resp *iam.Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	if resp != nil {
+		return fmt.Errorf("%s: unexpected status %d", action, resp.StatusCode())
+	}
+	return fmt.Errorf("%s: no response", action)
+}
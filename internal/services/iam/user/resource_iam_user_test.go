@@ -8,7 +8,9 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
 )
 
 func TestAccResourceIAMUser_basic(t *testing.T) {
@@ -36,6 +38,77 @@ func TestAccResourceIAMUser_basic(t *testing.T) {
 	})
 }
 
+func TestResourceIAMUser_lifecycle(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_user.test"
+	var userID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		// Destroying keeps the disabled account
+		CheckDestroy: func(_ *terraform.State) error {
+			if state, ok := fake.User(userID); !ok || !state.Disabled {
+				return fmt.Errorf("user was not kept disabled")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceIAMUserLifecycle(fake.RootOrgID, false, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "disabled", "false"),
+					func(s *terraform.State) error {
+						userID = s.RootModule().Resources[resourceName].Primary.ID
+						return nil
+					},
+				),
+			},
+			{
+				// Lockout and MFA enrollment happen outside Terraform
+				PreConfig: func() {
+					fake.LockUser(userID)
+					fake.SetUserMFA(userID, true)
+				},
+				Config: fake.ProviderConfig() + testResourceIAMUserLifecycle(fake.RootOrgID, true, "2024-01"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "disabled", "true"),
+					resource.TestCheckResourceAttr(resourceName, "locked", "false"),
+					resource.TestCheckResourceAttr(resourceName, "must_change_password", "true"),
+					func(_ *terraform.State) error {
+						state, _ := fake.User(userID)
+						if !state.Disabled || state.Locked || state.MFAResets != 1 || !state.MFA || state.Activations != 2 || !state.MustChangePassword {
+							return fmt.Errorf("unexpected account state: %+v", state)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testResourceIAMUserLifecycle(orgID string, disabled bool, trigger string) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_user" "test" {
+  login              = "lifecycle"
+  email              = "lifecycle@example.com"
+  first_name         = "Life"
+  last_name          = "Cycle"
+  organization_id    = "%s"
+  disabled           = %t
+  disable_on_destroy = true
+
+  unlock_trigger                = "%s"
+  mfa_reset_trigger             = "%s"
+  resend_activation_trigger     = "%s"
+  force_password_change_trigger = "%s"
+}
+`, orgID, disabled, trigger, trigger, trigger, trigger)
+}
+
 func testAccResourceIAMUser(parentOrgID, name, password string) string {
 	return fmt.Sprintf(`
 resource "hsdp_iam_user" "test" {
@@ -62,3 +135,65 @@ resource "hsdp_iam_group" "test" {
 		parentOrgID,
 	)
 }
+
+func TestResourceIAMUser_passwordWO(t *testing.T) {
+	fake := fakehsdp.New(t)
+	resourceName := "hsdp_iam_user.test"
+	var userID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testResourceIAMUserPasswordWO(fake.RootOrgID, "First-Passw0rd!", 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "password_wo"),
+					resource.TestCheckResourceAttr(resourceName, "password", ""),
+					resource.TestCheckResourceAttr(resourceName, "password_wo_version", "1"),
+					func(s *terraform.State) error {
+						userID = s.RootModule().Resources[resourceName].Primary.ID
+						if password := fake.UserPassword(userID); password != "First-Passw0rd!" {
+							return fmt.Errorf("unexpected user password %q", password)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// Like password, a version bump keeps the user and its actual password
+				Config: fake.ProviderConfig() + testResourceIAMUserPasswordWO(fake.RootOrgID, "Second-Passw0rd!", 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(resourceName, "password_wo"),
+					resource.TestCheckResourceAttr(resourceName, "password_wo_version", "2"),
+					func(s *terraform.State) error {
+						id := s.RootModule().Resources[resourceName].Primary.ID
+						if id != userID {
+							return fmt.Errorf("expected the user to be kept")
+						}
+						if password := fake.UserPassword(id); password != "First-Passw0rd!" {
+							return fmt.Errorf("unexpected user password %q", password)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testResourceIAMUserPasswordWO(orgID, password string, version int) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_user" "test" {
+  login               = "writeonly"
+  email               = "writeonly@example.com"
+  first_name          = "Write"
+  last_name           = "Only"
+  organization_id     = "%s"
+  password_wo         = "%s"
+  password_wo_version = %d
+}
+`, orgID, password, version)
+}
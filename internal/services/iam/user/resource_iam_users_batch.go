@@ -0,0 +1,520 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/config"
+	"github.com/philips-software/terraform-provider-hsdp/internal/tools"
+)
+
+const (
+	batchStatusCreated     = "created"
+	batchStatusUpdated     = "updated"
+	batchStatusUnchanged   = "unchanged"
+	batchStatusDeactivated = "deactivated"
+	batchStatusFailed      = "failed"
+)
+
+// batchCSVColumns are the columns a users CSV may contain. The header row is required.
+var batchCSVColumns = []string{"login", "email", "first_name", "last_name", "mobile", "preferred_language", "preferred_communication_channel"}
+
+func ResourceIAMUsersBatch() *schema.Resource {
+	return &schema.Resource{
+		Description: "Provisions many users of an organization at once. Users removed from the batch are deactivated.",
+
+		CreateContext: resourceIAMUsersBatchCreate,
+		ReadContext:   resourceIAMUsersBatchRead,
+		UpdateContext: resourceIAMUsersBatchUpdate,
+		DeleteContext: resourceIAMUsersBatchDelete,
+		CustomizeDiff: resourceIAMUsersBatchCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"organization_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managing organization of the users.",
+			},
+			"user": {
+				Type:         schema.TypeSet,
+				Optional:     true,
+				ExactlyOneOf: []string{"user", "csv"},
+				Elem:         batchUserSchema(),
+				Description:  "The users to provision.",
+			},
+			"csv": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"user", "csv"},
+				Description:  "The users to provision as CSV with a header row. Supported columns: " + strings.Join(batchCSVColumns, ", ") + ".",
+			},
+			"parallelism": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntBetween(1, 50),
+				Description:  "The number of users which are created, updated or deactivated concurrently.",
+			},
+			"user_ids": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        tools.StringSchema(),
+				Description: "The user IDs by login.",
+			},
+			"results": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        tools.StringSchema(),
+				Description: "The outcome of the last apply by login.",
+			},
+		},
+	}
+}
+
+func batchUserSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"login": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"email": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"first_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"last_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"mobile": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"preferred_language": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"preferred_communication_channel": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+// batchUser is a single user of a batch, keyed by its lower case login
+type batchUser struct {
+	Login                         string
+	Email                         string
+	FirstName                     string
+	LastName                      string
+	Mobile                        string
+	PreferredLanguage             string
+	PreferredCommunicationChannel string
+}
+
+func (u batchUser) key() string {
+	return strings.ToLower(u.Login)
+}
+
+// expandBatchUsers returns the users from either the user blocks or the CSV
+func expandBatchUsers(users *schema.Set, csvData string) (map[string]batchUser, error) {
+	var list []batchUser
+	if csvData != "" {
+		var err error
+		if list, err = parseBatchCSV(csvData); err != nil {
+			return nil, err
+		}
+	} else if users != nil {
+		for _, raw := range users.List() {
+			u := raw.(map[string]interface{})
+			list = append(list, batchUser{
+				Login:                         u["login"].(string),
+				Email:                         u["email"].(string),
+				FirstName:                     u["first_name"].(string),
+				LastName:                      u["last_name"].(string),
+				Mobile:                        u["mobile"].(string),
+				PreferredLanguage:             u["preferred_language"].(string),
+				PreferredCommunicationChannel: u["preferred_communication_channel"].(string),
+			})
+		}
+	}
+	result := make(map[string]batchUser, len(list))
+	for _, u := range list {
+		if _, ok := result[u.key()]; ok {
+			return nil, fmt.Errorf("duplicate login '%s'", u.Login)
+		}
+		result[u.key()] = u
+	}
+	return result, nil
+}
+
+func parseBatchCSV(data string) ([]batchUser, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !tools.ContainsString(batchCSVColumns, name) {
+			return nil, fmt.Errorf("unsupported CSV column '%s'", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range batchCSVColumns[:4] {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing CSV column '%s'", required)
+		}
+	}
+	var users []batchUser
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return users, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		line, _ := reader.FieldPos(0)
+		u := batchUser{
+			Login:                         field("login"),
+			Email:                         field("email"),
+			FirstName:                     field("first_name"),
+			LastName:                      field("last_name"),
+			Mobile:                        field("mobile"),
+			PreferredLanguage:             field("preferred_language"),
+			PreferredCommunicationChannel: field("preferred_communication_channel"),
+		}
+		if u.Login == "" || u.Email == "" || u.FirstName == "" || u.LastName == "" {
+			return nil, fmt.Errorf("CSV line %d: login, email, first_name and last_name are required", line)
+		}
+		users = append(users, u)
+	}
+}
+
+func desiredBatchUsers(d interface {
+	Get(string) interface{}
+}) (map[string]batchUser, error) {
+	users, _ := d.Get("user").(*schema.Set)
+	return expandBatchUsers(users, d.Get("csv").(string))
+}
+
+func previousBatchUsers(d *schema.ResourceData) map[string]batchUser {
+	oldUsers, _ := d.GetChange("user")
+	oldCSV, _ := d.GetChange("csv")
+	users, _ := oldUsers.(*schema.Set)
+	previous, err := expandBatchUsers(users, oldCSV.(string))
+	if err != nil {
+		return map[string]batchUser{}
+	}
+	return previous
+}
+
+func stringMap(raw interface{}) map[string]string {
+	result := make(map[string]string)
+	for k, v := range raw.(map[string]interface{}) {
+		result[k] = v.(string)
+	}
+	return result
+}
+
+// resourceIAMUsersBatchCustomizeDiff validates the input and plans another
+// apply while users are left over from failures or removed out of band
+func resourceIAMUsersBatchCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("user") || !d.NewValueKnown("csv") {
+		return nil
+	}
+	desired, err := desiredBatchUsers(d)
+	if err != nil {
+		return err
+	}
+	if d.Id() == "" {
+		return nil
+	}
+	ids := stringMap(d.Get("user_ids"))
+	results := stringMap(d.Get("results"))
+	pending := false
+	for key := range desired {
+		if _, ok := ids[key]; !ok {
+			pending = true
+		}
+	}
+	for key := range ids {
+		if _, ok := desired[key]; !ok {
+			pending = true
+		}
+	}
+	for _, result := range results {
+		if strings.HasPrefix(result, batchStatusFailed) {
+			pending = true
+		}
+	}
+	if pending {
+		_ = d.SetNewComputed("results")
+		_ = d.SetNewComputed("user_ids")
+	}
+	return nil
+}
+
+// batchResult is the outcome of a single user operation
+type batchResult struct {
+	key    string
+	id     string
+	status string
+	err    error
+}
+
+// runBatch runs the jobs with at most parallelism of them at the same time
+func runBatch(parallelism int, jobs []func() batchResult) []batchResult {
+	results := make([]batchResult, len(jobs))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job func() batchResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = job()
+		}(i, job)
+	}
+	wg.Wait()
+	return results
+}
+
+func createBatchUser(client *iam.Client, organization string, u batchUser) (string, string, error) {
+	existing, _, err := client.Users.GetUserByID(u.Login)
+	if err != nil && !errors.Is(err, iam.ErrEmptyResults) {
+		return "", "", fmt.Errorf("looking up existing user: %w", err)
+	}
+	if err == nil && existing != nil && existing.ID != "" {
+		if existing.ManagingOrganization != organization {
+			return "", "", fmt.Errorf("user already exists but is managed by a different IAM organization")
+		}
+		// Adopt the user, which might have been deactivated by a previous apply
+		if err := updateBatchUser(client, existing.ID, u); err != nil {
+			return "", "", err
+		}
+		return existing.ID, batchStatusUpdated, nil
+	}
+	person := iam.Person{
+		ResourceType: "Person",
+		Name: iam.Name{
+			Family: u.LastName,
+			Given:  u.FirstName,
+		},
+		LoginID: u.Login,
+		Telecom: []iam.TelecomEntry{
+			{
+				System: "email",
+				Value:  u.Email,
+			},
+		},
+		ManagingOrganization:          organization,
+		PreferredLanguage:             u.PreferredLanguage,
+		PreferredCommunicationChannel: u.PreferredCommunicationChannel,
+		IsAgeValidated:                "true",
+	}
+	if u.Mobile != "" {
+		person.Telecom = append(person.Telecom, iam.TelecomEntry{
+			System: "mobile",
+			Value:  u.Mobile,
+		})
+	}
+	created, resp, err := client.Users.CreateUser(person)
+	if err != nil {
+		return "", "", err
+	}
+	if created == nil {
+		return "", "", fmt.Errorf("empty response creating user: %v", resp)
+	}
+	return created.ID, batchStatusCreated, nil
+}
+
+func updateBatchUser(client *iam.Client, id string, u batchUser) error {
+	enabled := false
+	return updateProfile(client, id, func(profile *iam.Profile) {
+		profile.GivenName = u.FirstName
+		profile.FamilyName = u.LastName
+		profile.PreferredLanguage = u.PreferredLanguage
+		profile.PreferredCommunicationChannel = u.PreferredCommunicationChannel
+		profile.Contact.EmailAddress = u.Email
+		profile.Contact.MobilePhone = u.Mobile
+		profile.Disabled = &enabled
+	})
+}
+
+func deactivateBatchUser(client *iam.Client, id string) error {
+	disabled := true
+	return updateProfile(client, id, func(profile *iam.Profile) {
+		profile.Disabled = &disabled
+	})
+}
+
+// applyUsersBatch brings the users of the batch in line with desired. Every
+// user is attempted; failures are reported as warnings and retried on the
+// next apply.
+func applyUsersBatch(d *schema.ResourceData, client *iam.Client, previous, desired map[string]batchUser) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	organization := d.Get("organization_id").(string)
+	// The plan marks both maps unknown while work is pending, so use the prior state
+	oldIDs, _ := d.GetChange("user_ids")
+	oldResults, _ := d.GetChange("results")
+	ids := stringMap(oldIDs)
+	previousResults := stringMap(oldResults)
+	results := make(map[string]string)
+
+	var jobs []func() batchResult
+	for key, u := range desired {
+		key, u := key, u
+		id, known := ids[key]
+		switch {
+		case !known:
+			jobs = append(jobs, func() batchResult {
+				id, status, err := createBatchUser(client, organization, u)
+				return batchResult{key: key, id: id, status: status, err: err}
+			})
+		case previous[key] != u || strings.HasPrefix(previousResults[key], batchStatusFailed):
+			jobs = append(jobs, func() batchResult {
+				return batchResult{key: key, id: id, status: batchStatusUpdated, err: updateBatchUser(client, id, u)}
+			})
+		default:
+			results[key] = batchStatusUnchanged
+		}
+	}
+	for key, id := range ids {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		key, id := key, id
+		jobs = append(jobs, func() batchResult {
+			return batchResult{key: key, id: id, status: batchStatusDeactivated, err: deactivateBatchUser(client, id)}
+		})
+	}
+
+	for _, result := range runBatch(d.Get("parallelism").(int), jobs) {
+		if result.err != nil {
+			results[result.key] = fmt.Sprintf("%s: %v", batchStatusFailed, result.err)
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("user '%s' failed", result.key),
+				Detail:   result.err.Error(),
+			})
+			continue
+		}
+		results[result.key] = result.status
+		if result.status == batchStatusDeactivated {
+			delete(ids, result.key)
+		} else {
+			ids[result.key] = result.id
+		}
+	}
+	_ = d.Set("user_ids", ids)
+	_ = d.Set("results", results)
+	if len(diags) > 0 {
+		sort.Slice(diags, func(i, j int) bool { return diags[i].Summary < diags[j].Summary })
+	}
+	return diags
+}
+
+func resourceIAMUsersBatchCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	desired, err := desiredBatchUsers(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(d.Get("organization_id").(string))
+	diags := applyUsersBatch(d, client, map[string]batchUser{}, desired)
+	return append(diags, resourceIAMUsersBatchRead(ctx, d, m)...)
+}
+
+func resourceIAMUsersBatchRead(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	c := m.(*config.Config)
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	organization := d.Get("organization_id").(string)
+	existing, _, err := client.Users.GetAllUsers(&iam.GetUserOptions{
+		OrganizationID: &organization,
+	})
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("listing users of organization: %w", err))
+	}
+	found := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+	// Users deleted outside of Terraform are created again on the next apply
+	ids := stringMap(d.Get("user_ids"))
+	for key, id := range ids {
+		if !found[id] {
+			delete(ids, key)
+		}
+	}
+	_ = d.Set("user_ids", ids)
+	return diags
+}
+
+func resourceIAMUsersBatchUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	desired, err := desiredBatchUsers(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	diags := applyUsersBatch(d, client, previousBatchUsers(d), desired)
+	return append(diags, resourceIAMUsersBatchRead(ctx, d, m)...)
+}
+
+func resourceIAMUsersBatchDelete(_ context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*config.Config)
+	client, err := c.IAMClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	diags := applyUsersBatch(d, client, nil, map[string]batchUser{})
+	if len(diags) > 0 {
+		// Keep the batch so the remaining users are deactivated on the next destroy
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "not all users were deactivated",
+		})
+	}
+	d.SetId("")
+	return diags
+}
@@ -0,0 +1,55 @@
+package user
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/philips-software/go-hsdp-api/iam"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBatchUserLookup(t *testing.T) {
+	fake := fakehsdp.New(t)
+	client, err := iam.NewClient(nil, &iam.Config{
+		Region:         "us-east",
+		Environment:    "client-test",
+		OAuth2ClientID: fakehsdp.ClientID,
+		OAuth2Secret:   fakehsdp.ClientPassword,
+		IAMURL:         fake.URL,
+		IDMURL:         fake.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Login(fakehsdp.AdminUsername, fakehsdp.AdminPassword))
+
+	// An unknown login is created
+	u := batchUser{Login: "new", Email: "new@example.com", FirstName: "New", LastName: "User"}
+	id, status, err := createBatchUser(client, fake.RootOrgID, u)
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, batchStatusCreated, status)
+
+	// A failed lookup is reported instead of creating the user
+	var creates int
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			creates++
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	failingClient, err := iam.NewClient(nil, &iam.Config{
+		Region:      "us-east",
+		Environment: "client-test",
+		IAMURL:      failing.URL,
+		IDMURL:      failing.URL,
+	})
+	require.NoError(t, err)
+	_, _, err = createBatchUser(failingClient, fake.RootOrgID, u)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "looking up existing user")
+	}
+	assert.Zero(t, creates)
+}
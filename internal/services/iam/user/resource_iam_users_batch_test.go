@@ -0,0 +1,85 @@
+package user_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/philips-software/terraform-provider-hsdp/internal/acc"
+	"github.com/philips-software/terraform-provider-hsdp/internal/fakehsdp"
+)
+
+func TestResourceIAMUsersBatch_fake(t *testing.T) {
+	fake := fakehsdp.New(t)
+	// A login taken in another organization fails on its own
+	fake.AddUser("taken", "Passw0rd!fake", fake.AddOrganization("other", fake.RootOrgID))
+	resourceName := "hsdp_iam_users_batch.test"
+	var removedID string
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() {
+			acc.PreCheckFake(t)
+		},
+		ProviderFactories: acc.ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fake.ProviderConfig() + testResourceIAMUsersBatch(fake.RootOrgID, "login,email,first_name\nuser,user@example.com,User\n"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("missing CSV column 'last_name'"),
+			},
+			{
+				Config: fake.ProviderConfig() + testResourceIAMUsersBatch(fake.RootOrgID, testUsersCSV(30)+"taken,taken@example.com,Taken,User\n"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "user_ids.%", "30"),
+					resource.TestCheckResourceAttr(resourceName, "results.user00", "created"),
+					resource.TestMatchResourceAttr(resourceName, "results.taken", regexp.MustCompile("^failed: .*different IAM organization")),
+					func(s *terraform.State) error {
+						removedID = s.RootModule().Resources[resourceName].Primary.Attributes["user_ids.user29"]
+						return nil
+					},
+				),
+				// The failed user is retried
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// Removed users are deactivated, changed users updated
+				Config: fake.ProviderConfig() + testResourceIAMUsersBatch(fake.RootOrgID, strings.Replace(testUsersCSV(29), "Last00", "Changed", 1)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "user_ids.%", "29"),
+					resource.TestCheckResourceAttr(resourceName, "results.user00", "updated"),
+					resource.TestCheckResourceAttr(resourceName, "results.user01", "unchanged"),
+					resource.TestCheckResourceAttr(resourceName, "results.user29", "deactivated"),
+					func(_ *terraform.State) error {
+						if state, ok := fake.User(removedID); !ok || !state.Disabled {
+							return fmt.Errorf("user29 was not deactivated")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testUsersCSV(count int) string {
+	var b strings.Builder
+	b.WriteString("login,email,first_name,last_name\n")
+	for i := 0; i < count; i++ {
+		_, _ = fmt.Fprintf(&b, "user%02d,user%02d@example.com,User,Last%02d\n", i, i, i)
+	}
+	return b.String()
+}
+
+func testResourceIAMUsersBatch(orgID, csv string) string {
+	return fmt.Sprintf(`
+resource "hsdp_iam_users_batch" "test" {
+  organization_id = "%s"
+  parallelism     = 4
+  csv             = <<EOT
+%sEOT
+}
+`, orgID, csv)
+}
@@ -4,10 +4,14 @@ import (
 	"net/http"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/philips-software/go-hsdp-api/dicom"
 	"github.com/philips-software/go-hsdp-api/iam"
 )
 
 func CheckForPermissionErrors(client iam.TokenRefresher, status iam.HTTPStatus, err error) error {
+	if resp, ok := status.(*dicom.Response); ok && resp != nil && RetriesExhausted(resp.Response) {
+		return backoff.Permanent(err)
+	}
 	if status != nil && status.StatusCode() > 500 {
 		return err
 	}
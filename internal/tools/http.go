@@ -8,10 +8,20 @@ import (
 	"github.com/cenkalti/backoff/v4"
 )
 
+// RetriesExhaustedHeader is set on responses the provider HTTP clients already
+// retried according to the provider retry policy
+const RetriesExhaustedHeader = "X-Hsdp-Provider-Retries"
+
 var (
 	StandardRetryOnCodes = []int{http.StatusForbidden, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout}
 )
 
+// RetriesExhausted reports whether resp was already retried according to the
+// provider retry policy, in which case callers should not retry it again
+func RetriesExhausted(resp *http.Response) bool {
+	return resp != nil && resp.Header.Get(RetriesExhaustedHeader) != ""
+}
+
 func TryHTTPCall(ctx context.Context, numberOfTries uint64, operation func() (*http.Response, error), retryOnCodes ...int) error {
 	if len(retryOnCodes) == 0 {
 		retryOnCodes = StandardRetryOnCodes
@@ -32,6 +42,10 @@ func TryHTTPCall(ctx context.Context, numberOfTries uint64, operation func() (*h
 			err = fmt.Errorf("response was nil: %w", err)
 			shouldRetry = true
 		}
+		if RetriesExhausted(resp) {
+			// Retrying again would multiply the attempts of the retry policy
+			return backoff.Permanent(fmt.Errorf("HTTP %d after %s attempts: %w", resp.StatusCode, resp.Header.Get(RetriesExhaustedHeader), err))
+		}
 		if resp != nil {
 			for _, c := range retryOnCodes {
 				if c == resp.StatusCode {
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/philips-software/go-hsdp-api/dicom"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingRefresher struct {
+	refreshed int
+}
+
+func (r *countingRefresher) TokenRefresh() error {
+	r.refreshed++
+	return nil
+}
+
+func exhausted(status int) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	resp.Header.Set(RetriesExhaustedHeader, "3")
+	return resp
+}
+
+func TestTryHTTPCallStopsWhenRetriesExhausted(t *testing.T) {
+	calls := 0
+	err := TryHTTPCall(context.Background(), 8, func() (*http.Response, error) {
+		calls++
+		return exhausted(http.StatusTooManyRequests), errors.New("too many requests")
+	})
+	assert.ErrorContains(t, err, "HTTP 429 after 3 attempts")
+	assert.Equal(t, 1, calls)
+}
+
+func TestCheckersStopWhenRetriesExhausted(t *testing.T) {
+	refresher := &countingRefresher{}
+	cause := errors.New("service unavailable")
+	var permanent *backoff.PermanentError
+
+	err := CheckForIAMPermissionErrors(refresher, exhausted(http.StatusServiceUnavailable), cause)
+	assert.ErrorAs(t, err, &permanent)
+	err = CheckForIAMPermissionErrors(refresher, &http.Response{StatusCode: http.StatusServiceUnavailable}, cause)
+	assert.False(t, errors.As(err, &permanent))
+
+	err = CheckForPermissionErrors(refresher, &dicom.Response{Response: exhausted(http.StatusForbidden)}, cause)
+	assert.ErrorAs(t, err, &permanent)
+	assert.Equal(t, 0, refresher.refreshed)
+	err = CheckForPermissionErrors(refresher, &dicom.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}, cause)
+	assert.False(t, errors.As(err, &permanent))
+	assert.Equal(t, 1, refresher.refreshed)
+}
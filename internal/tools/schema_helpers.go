@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -27,3 +29,30 @@ func SchemaSetInts(l []int) *schema.Set {
 	}
 	return s
 }
+
+// WriteOnlyString returns the configured value of a WriteOnly string attribute
+//
+// WriteOnly values are never persisted, so they are only available from the
+// raw configuration during Create and Update and never through d.Get.
+func WriteOnlyString(d *schema.ResourceData, key string) (string, diag.Diagnostics) {
+	if d.GetRawConfig().IsNull() {
+		return "", nil
+	}
+	value, diags := d.GetRawConfigAt(cty.GetAttrPath(key))
+	if diags.HasError() {
+		return "", diags
+	}
+	if value.IsNull() || !value.IsKnown() || !value.Type().Equals(cty.String) {
+		return "", nil
+	}
+	return value.AsString(), nil
+}
+
+// SecretString returns the value of key, or of its WriteOnly variant woKey
+// when key is not set
+func SecretString(d *schema.ResourceData, key, woKey string) (string, diag.Diagnostics) {
+	if value := d.Get(key).(string); value != "" {
+		return value, nil
+	}
+	return WriteOnlyString(d, woKey)
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// MergeTags returns the provider default tags overlaid with the tags of a resource
+func MergeTags(defaults map[string]string, tags map[string]interface{}) map[string]string {
+	all := make(map[string]string, len(defaults)+len(tags))
+	for k, v := range defaults {
+		all[k] = v
+	}
+	for k, v := range tags {
+		if s, ok := v.(string); ok {
+			all[k] = s
+		}
+	}
+	return all
+}
+
+// StripDefaultTags returns all tags except the default tags the resource
+// does not set itself, so tags only holds what is configured
+func StripDefaultTags(all, defaults map[string]string, configured map[string]interface{}) map[string]string {
+	tags := make(map[string]string, len(all))
+	for k, v := range all {
+		if value, ok := defaults[k]; ok && value == v {
+			if _, ok := configured[k]; !ok {
+				continue
+			}
+		}
+		tags[k] = v
+	}
+	return tags
+}
+
+// defaultStringTags returns the default tags as sorted key=value strings
+func defaultStringTags(defaults map[string]string) []string {
+	list := make([]string, 0, len(defaults))
+	for k, v := range defaults {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// stringTagKey returns the key of a key=value string tag
+func stringTagKey(tag string) string {
+	key, _, _ := strings.Cut(tag, "=")
+	return key
+}
+
+// MergeStringTags returns the labels of a resource followed by the provider
+// default tags as key=value strings, skipping keys the labels already set
+func MergeStringTags(defaults map[string]string, labels []string) []string {
+	all := append([]string{}, labels...)
+	set := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		set[stringTagKey(label)] = true
+	}
+	for _, tag := range defaultStringTags(defaults) {
+		if !set[stringTagKey(tag)] {
+			all = append(all, tag)
+		}
+	}
+	return all
+}
+
+// StripDefaultStringTags is StripDefaultTags for key=value string tags
+func StripDefaultStringTags(all []string, defaults map[string]string, configured []string) []string {
+	isDefault := make(map[string]bool, len(defaults))
+	for _, tag := range defaultStringTags(defaults) {
+		isDefault[tag] = true
+	}
+	labels := make([]string, 0, len(all))
+	for _, label := range all {
+		if isDefault[label] && !ContainsString(configured, label) {
+			continue
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+// CustomizeDiffTagsAll plans tags_all as the merge of the provider default
+// tags and the tags attribute of a resource. Empty tags and ignoreKeys, which
+// the service does not report back, are left out.
+func CustomizeDiffTagsAll(d *schema.ResourceDiff, defaults map[string]string, ignoreKeys ...string) error {
+	if !d.NewValueKnown("tags") {
+		return d.SetNewComputed("tags_all")
+	}
+	all := MergeTags(defaults, d.Get("tags").(map[string]interface{}))
+	for k, v := range all {
+		if v == "" || ContainsString(ignoreKeys, k) {
+			delete(all, k)
+		}
+	}
+	if maps.Equal(MergeTags(nil, d.Get("tags_all").(map[string]interface{})), all) {
+		return nil
+	}
+	return d.SetNew("tags_all", all)
+}
+
+// CustomizeDiffStringTagsAll plans allKey as the merge of the provider default
+// tags and the string tags in key, which can be a list or a set
+func CustomizeDiffStringTagsAll(d *schema.ResourceDiff, key, allKey string, defaults map[string]string) error {
+	if !d.NewValueKnown(key) {
+		return d.SetNewComputed(allKey)
+	}
+	all := MergeStringTags(defaults, stringList(d.Get(key)))
+	current := stringList(d.Get(allKey))
+	if _, isSet := d.Get(allKey).(*schema.Set); isSet {
+		sort.Strings(all)
+		sort.Strings(current)
+	}
+	if slices.Equal(current, all) {
+		return nil
+	}
+	return d.SetNew(allKey, all)
+}
+
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case *schema.Set:
+		return ExpandStringList(list.List())
+	case []interface{}:
+		return ExpandStringList(list)
+	}
+	return []string{}
+}
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTags(t *testing.T) {
+	defaults := map[string]string{"team": "core", "cost_center": "42"}
+	all := MergeTags(defaults, map[string]interface{}{"team": "edge", "env": "dev"})
+	assert.Equal(t, map[string]string{"team": "edge", "cost_center": "42", "env": "dev"}, all)
+
+	// Default tags the resource does not set are left out of tags
+	tags := StripDefaultTags(all, defaults, map[string]interface{}{"team": "edge", "env": "dev"})
+	assert.Equal(t, map[string]string{"team": "edge", "env": "dev"}, tags)
+	tags = StripDefaultTags(all, defaults, map[string]interface{}{"cost_center": "42"})
+	assert.Equal(t, map[string]string{"team": "edge", "cost_center": "42", "env": "dev"}, tags)
+}
+
+func TestMergeStringTags(t *testing.T) {
+	defaults := map[string]string{"team": "core", "cost_center": "42"}
+	all := MergeStringTags(defaults, []string{"team=edge", "gpu"})
+	assert.Equal(t, []string{"team=edge", "gpu", "cost_center=42"}, all)
+	assert.Equal(t, []string{"cost_center=42", "team=core"}, MergeStringTags(defaults, nil))
+
+	assert.Equal(t, []string{"team=edge", "gpu"}, StripDefaultStringTags(all, defaults, []string{"team=edge", "gpu"}))
+	assert.Equal(t, all, StripDefaultStringTags(all, defaults, []string{"cost_center=42"}))
+}
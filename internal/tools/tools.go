@@ -139,6 +139,9 @@ func CollectList(fieldName string, d *schema.ResourceData) ([]string, diag.Diagn
 }
 
 func CheckForIAMPermissionErrors(client iam.TokenRefresher, resp *http.Response, err error) error {
+	if RetriesExhausted(resp) {
+		return backoff.Permanent(err)
+	}
 	if resp == nil || resp.StatusCode > 500 {
 		return err
 	}
@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"log"
 
 	"github.com/philips-software/terraform-provider-hsdp/hsdp"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 )
 
 var commit = "deadbeef"
@@ -21,12 +22,18 @@ func main() {
 	flag.BoolVar(&debugMode, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	opts := &plugin.ServeOpts{ProviderFunc: func() *schema.Provider {
-		return hsdp.Provider(buildVersion)
-	}}
+	providerServer, err := hsdp.ProtoV5ProviderServerFactory(context.Background(), buildVersion)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var serveOpts []tf5server.ServeOpt
 	if debugMode {
-		opts.Debug = true
-		opts.ProviderAddr = "registry.terraform.io/philips-software/hsdp"
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
+	}
+
+	err = tf5server.Serve("registry.terraform.io/philips-software/hsdp", providerServer, serveOpts...)
+	if err != nil {
+		log.Fatal(err)
 	}
-	plugin.Serve(opts)
 }